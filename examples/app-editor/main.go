@@ -24,31 +24,50 @@ import (
 
 	"github.com/google/go-react/pkg/agents"
 	"github.com/google/go-react/pkg/llms"
+	"github.com/google/go-react/pkg/llms/ollama"
+	"github.com/google/go-react/pkg/llms/openai"
 	"github.com/google/go-react/pkg/llms/vertex"
 	"github.com/google/go-react/pkg/predictors"
 	"github.com/google/go-react/pkg/prompters"
 )
 
-var model = flag.String("model", "text-bison@001", "The model to use for the prompt")
-var apiEndpoint = flag.String("api-endpoint", "us-central1-aiplatform.googleapis.com", "The API endpoint to use")
-var projectID = flag.String("project-id", os.Getenv("GCP_PROJECT_ID"), "The project ID to use")
+var provider = flag.String("provider", "vertex", "The LLM provider to use: vertex, openai or ollama")
+var model = flag.String("model", "", "The model to use for the prompt, defaults to the provider's (see defaultModels)")
+var apiEndpoint = flag.String("api-endpoint", "", "The API endpoint (vertex) or base URL (openai and ollama) to use, defaults to the provider's")
+var location = flag.String("location", vertex.DefaultLocation, "The location to send requests to (vertex)")
+var projectID = flag.String("project-id", os.Getenv("GCP_PROJECT_ID"), "The project ID to use (vertex)")
+var apiKey = flag.String("api-key", os.Getenv("OPENAI_API_KEY"), "The API key to use (openai)")
 var maxTokens = flag.Int("max-tokens", 1024, "The maximum number of tokens to generate")
 var temperature = flag.Float64("temperature", 0.2, "The temperature to use for the prompt")
 var topK = flag.Int("top-k", 40, "The top-k value to use for the prompt")
 var topP = flag.Float64("top-p", 0.9, "The top-p value to use for the prompt")
 
+// defaultModels are the models used for each provider when the model flag is
+// not set.
+var defaultModels = map[string]string{
+	"vertex": "text-bison@001",
+	"openai": "gpt-3.5-turbo",
+	"ollama": "llama2",
+}
+
 func main() {
 	flag.Parse()
 	ctx := context.Background()
 
-	defaultParams := vertex.Params{
+	if *model == "" {
+		*model = defaultModels[*provider]
+	}
+
+	// The params are not tied to a provider, so the same prompt is used
+	// whichever provider is picked.
+	defaultParams := llms.GenerationConfig{
 		Model:       *model,
 		MaxTokens:   *maxTokens,
 		Temperature: *temperature,
 		TopK:        *topK,
 		TopP:        *topP,
 	}
-	prompt := agents.NewDefaultPrompt[vertex.Params, string](defaultParams)
+	prompt := agents.NewDefaultPrompt[llms.GenerationConfig, string](defaultParams)
 	tempFile, err := os.CreateTemp("", "react")
 	if err != nil {
 		log.Fatalf("failed to create temp file: %s", err)
@@ -61,7 +80,7 @@ func main() {
 
 	parser := agents.NewDefaultParser[string]()
 	predictor := predictors.New(llm, prompt, parser)
-	predictor = agents.NewCLILogger(predictor, os.Stderr)
+	predictor = agents.NewCLILogger(predictor, os.Stderr, agents.WithCLILoggerStreaming[string]())
 	tools := SetupToyToolSet(llm)
 
 	for {
@@ -80,14 +99,36 @@ func main() {
 	}
 }
 
-func getLLM(ctx context.Context) llms.LLM[vertex.Params] {
-	if *projectID == "" {
-		log.Fatalf("you must set the project-id flag or GCP_PROJECT_ID environment variable")
-	}
+func getLLM(ctx context.Context) llms.LLM[llms.GenerationConfig] {
+	switch *provider {
+	case "vertex":
+		if *projectID == "" {
+			log.Fatalf("you must set the project-id flag or GCP_PROJECT_ID environment variable")
+		}
 
-	llm, err := vertex.New(ctx, *apiEndpoint, *projectID)
-	if err != nil {
-		log.Fatalf("failed to create LLM: %v", err)
+		llm, err := vertex.New(ctx, *apiEndpoint, *projectID, vertex.WithLocation(*location))
+		if err != nil {
+			log.Fatalf("failed to create LLM: %v", err)
+		}
+		return llms.MapParams(llm, vertex.FromGenerationConfig)
+	case "openai":
+		if *apiKey == "" {
+			log.Fatalf("you must set the api-key flag or OPENAI_API_KEY environment variable")
+		}
+
+		var opts []openai.Option
+		if *apiEndpoint != "" {
+			opts = append(opts, openai.WithBaseURL(*apiEndpoint))
+		}
+		return llms.MapParams(openai.New(*apiKey, opts...), openai.FromGenerationConfig)
+	case "ollama":
+		var opts []ollama.Option
+		if *apiEndpoint != "" {
+			opts = append(opts, ollama.WithBaseURL(*apiEndpoint))
+		}
+		return llms.MapParams(ollama.New(opts...), ollama.FromGenerationConfig)
+	default:
+		log.Fatalf("unknown provider %q", *provider)
+		return nil
 	}
-	return llm
 }
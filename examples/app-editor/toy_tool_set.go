@@ -26,12 +26,11 @@ import (
 
 	"github.com/google/go-react/pkg/chains/confirmation"
 	"github.com/google/go-react/pkg/llms"
-	"github.com/google/go-react/pkg/llms/vertex"
 	"github.com/google/go-react/pkg/tools"
 )
 
 // SetupToyToolSet returns a tool set of demo tools.
-func SetupToyToolSet(llm llms.LLM[vertex.Params]) []tools.Tool {
+func SetupToyToolSet(llm llms.LLM[llms.GenerationConfig]) []tools.Tool {
 	var tools []tools.Tool
 	var appTemplate appTemplate
 
@@ -102,7 +101,7 @@ func listTablesTool(appTemplate *appTemplate) tools.Tool {
 	}
 }
 
-func addTableTool(llm llms.LLM[vertex.Params], appTemplate *appTemplate) tools.Tool {
+func addTableTool(llm llms.LLM[llms.GenerationConfig], appTemplate *appTemplate) tools.Tool {
 	confirmationChain := confirmation.New(llm, os.Stdin, os.Stdout)
 	return tools.Tool{
 		Name:        "add-table",
@@ -135,7 +134,7 @@ func addTableTool(llm llms.LLM[vertex.Params], appTemplate *appTemplate) tools.T
 	}
 }
 
-func removeTableTool(llm llms.LLM[vertex.Params], appTemplate *appTemplate) tools.Tool {
+func removeTableTool(llm llms.LLM[llms.GenerationConfig], appTemplate *appTemplate) tools.Tool {
 	confirmationChain := confirmation.New(llm, os.Stdin, os.Stdout)
 	return tools.Tool{
 		Name:        "remove-table",
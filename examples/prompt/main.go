@@ -28,7 +28,8 @@ import (
 )
 
 var model = flag.String("model", "text-bison@001", "The model to use for the prompt")
-var apiEndpoint = flag.String("api-endpoint", "us-central1-aiplatform.googleapis.com", "The API endpoint to use")
+var apiEndpoint = flag.String("api-endpoint", "", "The API endpoint to use, defaults to the location's endpoint")
+var location = flag.String("location", vertex.DefaultLocation, "The location to send requests to")
 var projectID = flag.String("project-id", os.Getenv("GCP_PROJECT_ID"), "The project ID to use")
 var maxTokens = flag.Int("max-tokens", 1024, "The maximum number of tokens to generate")
 var temperature = flag.Float64("temperature", 0.2, "The temperature to use for the prompt")
@@ -74,7 +75,7 @@ func getLLM(ctx context.Context) llms.LLM[vertex.Params] {
 		log.Fatalf("you must set the project-id flag or GCP_PROJECT_ID environment variable")
 	}
 
-	llm, err := vertex.New(ctx, *apiEndpoint, *projectID)
+	llm, err := vertex.New(ctx, *apiEndpoint, *projectID, vertex.WithLocation(*location))
 	if err != nil {
 		log.Fatalf("failed to create LLM: %v", err)
 	}
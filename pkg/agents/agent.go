@@ -19,6 +19,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/google/go-react/pkg/llms"
 	"github.com/google/go-react/pkg/predictors"
 	"github.com/google/go-react/pkg/tools"
 )
@@ -49,6 +50,19 @@ type ThoughtIteration[TOut any] struct {
 	Observation     any `json:"observation,omitempty"`
 }
 
+// Result is the outcome of an agent's run.
+type Result[TOut any] struct {
+	FinalAnswer TOut `json:"final_answer"`
+	// Cost is the tokens used and their cost for all the LLM requests of the
+	// run. Only the requests to LLMs wrapped with llms.NewBudgetGuard or
+	// llms.NewChatBudgetGuard are recorded: completions, streams, candidates,
+	// function calling, multimodal and chat requests. The tokens are estimated
+	// for streams, chats and LLMs that don't report their usage.
+	Cost llms.Cost `json:"cost"`
+	// CostByModel is the Cost for each model.
+	CostByModel map[string]llms.Cost `json:"cost_by_model,omitempty"`
+}
+
 // PromptData is the data used to hydrate the ReAct prompt.
 type PromptData[TOut any] struct {
 	Tools    []tools.Tool
@@ -119,6 +133,24 @@ func validateTools(ts []tools.Tool) (map[string]tools.Tool, []tools.Tool, error)
 // Run a ReAct Agent in attempt a to reach the given goal. The FinalAnswer is
 // output unless there is an error first.
 func (a Agent[TOut]) Run(ctx context.Context, goal string) (TOut, error) {
+	result, err := a.RunWithResult(ctx, goal)
+	return result.FinalAnswer, err
+}
+
+// RunWithResult runs the agent like Run, but also returns the cost of the
+// run. The cost is returned even if the run fails (e.g., with
+// llms.ErrBudgetExceeded when the context has a budget the run exceeds).
+func (a Agent[TOut]) RunWithResult(ctx context.Context, goal string) (Result[TOut], error) {
+	budget := llms.NewBudget()
+	answer, err := a.run(llms.WithBudget(ctx, budget), goal)
+	return Result[TOut]{
+		FinalAnswer: answer,
+		Cost:        budget.Total(),
+		CostByModel: budget.ByModel(),
+	}, err
+}
+
+func (a Agent[TOut]) run(ctx context.Context, goal string) (TOut, error) {
 	if goal == "" {
 		var empty TOut
 		return empty, errors.New("goal is empty")
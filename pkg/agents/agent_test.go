@@ -16,12 +16,25 @@ package agents_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/google/go-react/pkg/agents"
+	"github.com/google/go-react/pkg/llms"
+	"github.com/google/go-react/pkg/llms/openai"
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+	"github.com/google/go-react/pkg/llms/vertex"
+	"github.com/google/go-react/pkg/llms/vertex/vertextest"
+	"github.com/google/go-react/pkg/parsers"
+	"github.com/google/go-react/pkg/predictors"
 	predictorstesting "github.com/google/go-react/pkg/predictors/testing"
+	prompterstesting "github.com/google/go-react/pkg/prompters/testing"
 	"github.com/google/go-react/pkg/tools"
 )
 
@@ -308,6 +321,245 @@ func TestAgent_invalidToolSetup(t *testing.T) {
 	}
 }
 
+func TestAgent_RunWithResult(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		budget         *llms.Budget
+		expectedAnswer FinalAnswer
+		expectedCost   llms.Cost
+		expectedErr    error
+	}{
+		{
+			name:           "records the cost",
+			expectedAnswer: "some-final-answer",
+			expectedCost: llms.Cost{
+				Usage:  llms.Usage{InputTokens: 20, OutputTokens: 40},
+				Amount: 0.0001,
+			},
+		},
+		{
+			name:   "budget exceeded",
+			budget: llms.NewBudget(llms.WithMaxTokens(50)),
+			// The first request is within the budget and the second exceeds it.
+			expectedCost: llms.Cost{
+				Usage:  llms.Usage{InputTokens: 20, OutputTokens: 40},
+				Amount: 0.0001,
+			},
+			expectedErr: llms.ErrBudgetExceeded,
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := &llmstesting.Fake[string]{
+				Results: map[string]llms.Result{
+					"prompt-1": {
+						Text:  `{"thought": "some-thought", "action": "foo tool", "input": "some-input"}`,
+						Usage: llms.Usage{InputTokens: 10, OutputTokens: 20},
+					},
+					"prompt-2": {
+						Text:  `{"thought": "some-thought", "final_answer": "some-final-answer"}`,
+						Usage: llms.Usage{InputTokens: 10, OutputTokens: 20},
+					},
+				},
+			}
+			llm := llms.NewBudgetGuard[string](fake, llms.PriceTable{
+				"some-model": {InputPerMillion: 1, OutputPerMillion: 2},
+			}, func(model string) string { return model })
+			prompter := &prompterstesting.Fake[agents.PromptData[FinalAnswer], string]{
+				HydrateF: func(ctx context.Context, data agents.PromptData[FinalAnswer]) (string, string, error) {
+					return fmt.Sprintf("prompt-%d", len(data.Chains)+1), "some-model", nil
+				},
+			}
+			agent := agents.NewAgent[FinalAnswer](
+				predictors.New[agents.PromptData[FinalAnswer]](llm, prompter, parsers.NewJSONParser[agents.Reasoning[FinalAnswer]]()),
+				buildFakeTool("foo tool"),
+			)
+
+			ctx := context.Background()
+			if tc.budget != nil {
+				ctx = llms.WithBudget(ctx, tc.budget)
+			}
+			result, err := agent.RunWithResult(ctx, "some goal")
+			if actual, expected := err, tc.expectedErr; !errors.Is(actual, expected) {
+				t.Fatalf("expected %v, got %v", expected, actual)
+			}
+
+			if actual, expected := result.FinalAnswer, tc.expectedAnswer; actual != expected {
+				t.Fatalf("expected %q, got %q", expected, actual)
+			}
+			if actual, expected := result.Cost, tc.expectedCost; actual != expected {
+				t.Fatalf("expected %+v, got %+v", expected, actual)
+			}
+			if actual, expected := result.CostByModel["some-model"], tc.expectedCost; actual != expected {
+				t.Fatalf("expected %+v, got %+v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestAgent_RunWithResult_chat(t *testing.T) {
+	t.Parallel()
+
+	fake := &llmstesting.FakeChat[string]{
+		ChatF: func(ctx context.Context, messages []llms.Message) (llms.Message, error) {
+			if messages[len(messages)-1].Content == "prompt-1" {
+				return llms.Message{Role: llms.RoleAssistant, Content: `{"thought": "some-thought", "action": "foo tool", "input": "some-input"}`}, nil
+			}
+			return llms.Message{Role: llms.RoleAssistant, Content: `{"thought": "some-thought", "final_answer": "some-final-answer"}`}, nil
+		},
+	}
+	chat := llms.NewChatBudgetGuard[string](fake, llms.PriceTable{
+		"some-model": {InputPerMillion: 1, OutputPerMillion: 2},
+	}, func(model string) string { return model })
+	prompter := &prompterstesting.FakeChat[agents.PromptData[FinalAnswer], string]{
+		HydrateF: func(ctx context.Context, data agents.PromptData[FinalAnswer]) ([]llms.Message, string, error) {
+			return []llms.Message{
+				{Role: llms.RoleSystem, Content: "some-system"},
+				{Role: llms.RoleUser, Content: fmt.Sprintf("prompt-%d", len(data.Chains)+1)},
+			}, "some-model", nil
+		},
+	}
+	agent := agents.NewAgent[FinalAnswer](
+		predictors.NewChat[agents.PromptData[FinalAnswer]](chat, prompter, parsers.NewJSONParser[agents.Reasoning[FinalAnswer]]()),
+		buildFakeTool("foo tool"),
+	)
+
+	result, err := agent.RunWithResult(context.Background(), "some goal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := result.FinalAnswer, FinalAnswer("some-final-answer"); actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+
+	// Both chat requests are estimated and recorded.
+	if actual, expected := len(fake.Messages), 2; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+	if result.Cost.Usage.InputTokens == 0 || result.Cost.Usage.OutputTokens == 0 || result.Cost.Amount == 0 {
+		t.Fatalf("expected a cost, got %+v", result.Cost)
+	}
+	if actual, expected := result.CostByModel["some-model"], result.Cost; actual != expected {
+		t.Fatalf("expected %+v, got %+v", expected, actual)
+	}
+}
+
+func TestAgent_Run_generationConfigChat(t *testing.T) {
+	t.Parallel()
+
+	const (
+		action = `{"thought": "some-thought", "action": "foo tool", "input": "some-input"}`
+		answer = `{"thought": "some-thought", "final_answer": "some-final-answer"}`
+	)
+
+	testCases := []struct {
+		name string
+		// newChat returns the backend's chat and a function that returns the
+		// models of the requests it received.
+		newChat func(t *testing.T) (llms.ChatLLM[llms.GenerationConfig], func() []string)
+	}{
+		{
+			name: "vertex",
+			newChat: func(t *testing.T) (llms.ChatLLM[llms.GenerationConfig], func() []string) {
+				srv := vertextest.NewServer()
+				t.Cleanup(srv.Close)
+				srv.Enqueue(vertextest.MethodGenerateContent,
+					vertextest.GenerateContentResponse(action),
+					vertextest.GenerateContentResponse(answer),
+				)
+
+				chat := vertex.NewChatWithKey("some-key", srv.Endpoint(), "some-project", srv.Options()...)
+				return llms.MapChatParams(chat, vertex.FromGenerationConfig), func() []string {
+					var models []string
+					for _, r := range srv.Requests() {
+						models = append(models, r.Model)
+					}
+					return models
+				}
+			},
+		},
+		{
+			name: "openai",
+			newChat: func(t *testing.T) (llms.ChatLLM[llms.GenerationConfig], func() []string) {
+				var (
+					mu     sync.Mutex
+					models []string
+				)
+				srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					var body struct {
+						Model string `json:"model"`
+					}
+					if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+						t.Error(err)
+					}
+
+					mu.Lock()
+					defer mu.Unlock()
+					models = append(models, body.Model)
+					content := action
+					if len(models) > 1 {
+						content = answer
+					}
+					json.NewEncoder(w).Encode(map[string]any{
+						"choices": []any{map[string]any{
+							"message": map[string]any{"role": "assistant", "content": content},
+						}},
+					})
+				}))
+				t.Cleanup(srv.Close)
+
+				chat := openai.NewChat("some-key", openai.WithBaseURL(srv.URL), openai.WithHTTPClient(srv.Client()))
+				return llms.MapChatParams(chat, openai.FromGenerationConfig), func() []string {
+					mu.Lock()
+					defer mu.Unlock()
+					return models
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			chat, models := tc.newChat(t)
+			// The same prompter is used with every backend.
+			prompter := &prompterstesting.FakeChat[agents.PromptData[FinalAnswer], llms.GenerationConfig]{
+				HydrateF: func(ctx context.Context, data agents.PromptData[FinalAnswer]) ([]llms.Message, llms.GenerationConfig, error) {
+					return []llms.Message{
+						{Role: llms.RoleSystem, Content: "some-system"},
+						{Role: llms.RoleUser, Content: data.Goal},
+					}, llms.GenerationConfig{Model: "gemini-1.5-pro", JSON: true}, nil
+				},
+			}
+			agent := agents.NewAgent[FinalAnswer](
+				predictors.NewChat[agents.PromptData[FinalAnswer]](chat, prompter, parsers.NewJSONParser[agents.Reasoning[FinalAnswer]]()),
+				buildFakeTool("foo tool"),
+			)
+
+			answer, err := agent.Run(context.Background(), "some goal")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual, expected := answer, FinalAnswer("some-final-answer"); actual != expected {
+				t.Fatalf("expected %q, got %q", expected, actual)
+			}
+			if actual, expected := strings.Join(models(), ","), "gemini-1.5-pro,gemini-1.5-pro"; actual != expected {
+				t.Fatalf("expected %q, got %q", expected, actual)
+			}
+		})
+	}
+}
+
 func buildInvalidTool() tools.Tool {
 	return tools.Tool{
 		Name:        "",
@@ -18,6 +18,9 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/google/go-react/pkg/predictors"
 )
@@ -31,12 +34,33 @@ func WithCLILoggerPrefix[TOut any](prefix string) func(*cliLogger[TOut]) {
 	}
 }
 
+// WithCLILoggerStreaming prints the Thought as it is streamed back from the
+// LLM instead of waiting for the full response. The Predictor being logged
+// must be one created with predictors.New.
+func WithCLILoggerStreaming[TOut any]() CLILoggerOption[TOut] {
+	return func(c *cliLogger[TOut]) {
+		c.stream = true
+	}
+}
+
 type cliLogger[TOut any] struct {
 	p      predictors.Predictor[PromptData[TOut], Reasoning[TOut]]
 	out    io.Writer
 	prefix string
+	stream bool
 }
 
+const (
+	// ANSI escape sequence for setting text color to green.
+	green = "\033[32m"
+
+	// ANSI escape sequence for setting text color to blue.
+	blue = "\033[34m"
+
+	// ANSI escape sequence for resetting text color to default.
+	reset = "\033[0m"
+)
+
 // NewCLILogger chains a Predictor that provides logging around the output of
 // the Predictor that is suitable for a CLI.
 func NewCLILogger[TOut any](
@@ -59,30 +83,38 @@ func (l cliLogger[TOut]) Predict(
 	ctx context.Context,
 	req PromptData[TOut],
 ) (Reasoning[TOut], error) {
-	resp, err := l.p.Predict(ctx, req)
-	if err != nil {
-		return resp, err
-	}
-
-	const (
-		// ANSI escape sequence for setting text color to green.
-		green = "\033[32m"
-
-		// ANSI escape sequence for setting text color to blue.
-		blue = "\033[34m"
-
-		// ANSI escape sequence for resetting text color to default.
-		reset = "\033[0m"
-	)
-
 	prefix := ""
 	if l.prefix != "" {
 		// Print the prefix in blue.
 		prefix = fmt.Sprintf("%s[%s]%s ", blue, l.prefix, reset)
 	}
 
+	var thoughts *thoughtStreamer
+	if l.stream {
+		thoughts = &thoughtStreamer{out: l.out, prefix: prefix}
+		ctx = predictors.WithStreamHandler(ctx, thoughts.write)
+	}
+
+	resp, err := l.p.Predict(ctx, req)
+	if thoughts != nil && thoughts.started {
+		// Finish off the streamed Thought.
+		fmt.Fprintf(l.out, "%s\n", reset)
+	}
+	if err != nil {
+		return resp, err
+	}
+
 	// Print out in green.
-	if resp.Action != "" {
+	if thoughts != nil && thoughts.started {
+		// The Thought has already been printed.
+		if resp.Action != "" {
+			fmt.Fprintf(l.out, "%s%sAction: %q Input: %q%s\n",
+				prefix, green, resp.Action, resp.Input, reset)
+		} else {
+			fmt.Fprintf(l.out, "%s%sFinalAnswer: %v%s\n",
+				prefix, green, resp.FinalAnswer, reset)
+		}
+	} else if resp.Action != "" {
 		fmt.Fprintf(l.out, "%s%sThought: %q Action: %q Input: %q%s\n",
 			prefix, green, resp.Thought, resp.Action, resp.Input, reset)
 	} else {
@@ -91,3 +123,86 @@ func (l cliLogger[TOut]) Predict(
 	}
 	return resp, nil
 }
+
+var thoughtPattern = regexp.MustCompile(`"thought"\s*:\s*"`)
+
+// thoughtStreamer prints the value of the "thought" field as the JSON
+// response is streamed back from the LLM.
+type thoughtStreamer struct {
+	out     io.Writer
+	prefix  string
+	buf     strings.Builder
+	printed int
+	started bool
+}
+
+func (t *thoughtStreamer) write(chunk string) {
+	t.buf.WriteString(chunk)
+
+	thought, ok := partialJSONString(t.buf.String(), thoughtPattern)
+	if !ok || len(thought) <= t.printed {
+		return
+	}
+	if !t.started {
+		t.started = true
+		fmt.Fprintf(t.out, "%s%sThought: ", t.prefix, green)
+	}
+	fmt.Fprint(t.out, thought[t.printed:])
+	t.printed = len(thought)
+}
+
+// partialJSONString returns the decoded value of the JSON string that
+// immediately follows the key pattern. The JSON might not be complete yet, so
+// only the fully received portion of the string is returned.
+func partialJSONString(data string, key *regexp.Regexp) (string, bool) {
+	loc := key.FindStringIndex(data)
+	if loc == nil {
+		return "", false
+	}
+	data = data[loc[1]:]
+
+	var b strings.Builder
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c == '"':
+			// End of the string.
+			return b.String(), true
+		case c != '\\':
+			b.WriteByte(c)
+			continue
+		}
+
+		// Handle an escape sequence, stopping if it hasn't been fully received.
+		if i+1 >= len(data) {
+			return b.String(), true
+		}
+		i++
+		switch data[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+4 >= len(data) {
+				return b.String(), true
+			}
+			r, err := strconv.ParseUint(data[i+1:i+5], 16, 32)
+			if err != nil {
+				return b.String(), true
+			}
+			i += 4
+			b.WriteRune(rune(r))
+		default:
+			// Covers \", \\ and \/.
+			b.WriteByte(data[i])
+		}
+	}
+	return b.String(), true
+}
@@ -21,7 +21,10 @@ import (
 	"testing"
 
 	"github.com/google/go-react/pkg/agents"
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+	"github.com/google/go-react/pkg/predictors"
 	predictorstesting "github.com/google/go-react/pkg/predictors/testing"
+	prompterstesting "github.com/google/go-react/pkg/prompters/testing"
 )
 
 func TestCLILogger(t *testing.T) {
@@ -138,3 +141,41 @@ func TestCLILogger(t *testing.T) {
 		})
 	}
 }
+
+func TestCLILogger_streaming(t *testing.T) {
+	t.Parallel()
+
+	llm := &llmstesting.Fake[int]{
+		Chunks: map[string][]string{
+			"some-prompt": {
+				`{"thought": "some \`,
+				`"quoted\" thou`,
+				`ght", "action": "some-action", "input": "some-input"}`,
+			},
+		},
+	}
+	prompter := &prompterstesting.Fake[agents.PromptData[FinalAnswer], int]{
+		HydrateF: func(context.Context, agents.PromptData[FinalAnswer]) (string, int, error) {
+			return "some-prompt", 0, nil
+		},
+	}
+	p := predictors.New[agents.PromptData[FinalAnswer], agents.Reasoning[FinalAnswer], int](
+		llm,
+		prompter,
+		agents.NewDefaultParser[FinalAnswer](),
+	)
+
+	buf := &bytes.Buffer{}
+	logger := agents.NewCLILogger[FinalAnswer](p, buf, agents.WithCLILoggerStreaming[FinalAnswer]())
+
+	resp, err := logger.Predict(context.Background(), agents.PromptData[FinalAnswer]{Goal: "some-goal"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := resp.Thought, `some "quoted" thought`; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+	if actual, expected := buf.String(), "\x1b[32mThought: some \"quoted\" thought\x1b[0m\n\x1b[32mAction: \"some-action\" Input: \"some-input\"\x1b[0m\n"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+}
@@ -17,6 +17,7 @@ package agents
 import (
 	"reflect"
 
+	"github.com/google/go-react/pkg/llms"
 	"github.com/google/go-react/pkg/parsers"
 	"github.com/google/go-react/pkg/prompters"
 )
@@ -24,7 +25,9 @@ import (
 const (
 	defaultPreamble = "What's the next thing you should do to answer the question with the given tools: "
 
-	defaultPrompt = `{{.Preamble}}
+	// defaultSystemPrompt explains the tools, rules and format. It is the
+	// system message of the default chat prompt.
+	defaultSystemPrompt = `{{.Preamble}}
 
 Tools [{{range .Tools}}{{.Name}} {{end}}]:
   {{range .Tools}}{{.Name}}: {{.Description}}{{if gt (len .Args) 0}}
@@ -63,15 +66,19 @@ Examples:
 
   {{end}}
 
-Begin!
+`
 
-Question: {{.Goal}}
+	// defaultUserPrompt asks the question given the previous context. It is
+	// the user message of the default chat prompt.
+	defaultUserPrompt = `Question: {{.Goal}}
 
 Previous context:
 {{range .Chains}}{{ToJSON .}}
 {{end}}
 Output:
 `
+
+	defaultPrompt = defaultSystemPrompt + "Begin!\n\n" + defaultUserPrompt
 )
 
 func withDefaultExamples[TLLMParams, TOut any]() prompters.Option[PromptData[TOut]] {
@@ -154,6 +161,32 @@ func NewDefaultPrompt[TLLMParams, TOut any](
 	params TLLMParams,
 	opts ...prompters.Option[PromptData[TOut]],
 ) prompters.Prompter[PromptData[TOut], TLLMParams] {
+	return prompters.NewTextTemplate[PromptData[TOut], TLLMParams](
+		defaultPrompt,
+		params,
+		defaultOptions[TLLMParams, TOut](opts)...,
+	)
+}
+
+// NewDefaultChatPrompt returns the default prompt for a ReAct loop that is
+// used with a llms.ChatLLM. The tools, rules and examples are sent as the
+// system message while the goal and previous context are sent as the user
+// message. It accepts the same options as NewDefaultPrompt.
+func NewDefaultChatPrompt[TLLMParams, TOut any](
+	params TLLMParams,
+	opts ...prompters.Option[PromptData[TOut]],
+) prompters.ChatPrompter[PromptData[TOut], TLLMParams] {
+	return prompters.NewChatTemplate[PromptData[TOut], TLLMParams](
+		[]prompters.MessageTemplate{
+			{Role: llms.RoleSystem, Text: defaultSystemPrompt},
+			{Role: llms.RoleUser, Text: defaultUserPrompt},
+		},
+		params,
+		defaultOptions[TLLMParams, TOut](opts)...,
+	)
+}
+
+func defaultOptions[TLLMParams, TOut any](opts []prompters.Option[PromptData[TOut]]) []prompters.Option[PromptData[TOut]] {
 	// Set the default examples. We want the user's options to come last so that
 	// they can override anything we've set with an option.
 	var options []prompters.Option[PromptData[TOut]]
@@ -162,9 +195,7 @@ func NewDefaultPrompt[TLLMParams, TOut any](
 	options = append(options, withDefaultExamples[TLLMParams, TOut]())
 	options = append(options, withDefaultExamples[TLLMParams, TOut]())
 
-	options = append(options, opts...)
-
-	return prompters.NewTextTemplate[PromptData[TOut], TLLMParams](defaultPrompt, params, options...)
+	return append(options, opts...)
 }
 
 // WithPreamble replaces the preamble.
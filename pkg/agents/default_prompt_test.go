@@ -20,6 +20,7 @@ import (
 	"testing"
 
 	"github.com/google/go-react/pkg/agents"
+	"github.com/google/go-react/pkg/llms"
 	"github.com/google/go-react/pkg/prompters"
 )
 
@@ -158,3 +159,41 @@ func TestDefaultPrompt_nonStringType(t *testing.T) {
 		})
 	}
 }
+
+func TestDefaultChatPrompt(t *testing.T) {
+	t.Parallel()
+
+	prompter := agents.NewDefaultChatPrompt[int, int](0, agents.WithPreamble[int, int]("some fancy preamble"))
+	messages, params, err := prompter.Hydrate(context.Background(), agents.PromptData[int]{
+		Goal: "some goal",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := params, 0; actual != expected {
+		t.Errorf("got %d, want %d", actual, expected)
+	}
+	if actual, expected := len(messages), 2; actual != expected {
+		t.Fatalf("got %d, want %d", actual, expected)
+	}
+
+	if actual, expected := messages[0].Role, llms.RoleSystem; actual != expected {
+		t.Errorf("got %q, want %q", actual, expected)
+	}
+	if actual, expected := strings.Contains(messages[0].Content, "some fancy preamble"), true; actual != expected {
+		t.Errorf("got %v, want %v", actual, expected)
+	}
+	if actual, expected := strings.Contains(messages[0].Content, "Example 0"), true; actual != expected {
+		t.Errorf("got %v, want %v", actual, expected)
+	}
+	if actual, expected := strings.Contains(messages[0].Content, "some goal"), false; actual != expected {
+		t.Errorf("got %v, want %v", actual, expected)
+	}
+
+	if actual, expected := messages[1].Role, llms.RoleUser; actual != expected {
+		t.Errorf("got %q, want %q", actual, expected)
+	}
+	if actual, expected := strings.HasPrefix(messages[1].Content, "Question: some goal"), true; actual != expected {
+		t.Errorf("got %v, want %v", actual, expected)
+	}
+}
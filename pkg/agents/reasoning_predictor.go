@@ -16,6 +16,7 @@ package agents
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"regexp"
@@ -89,6 +90,21 @@ func (p reasoningPredictor[TOut]) Predict(
 	return r, err
 }
 
+// VoteKey returns the key used to compare Reasonings with
+// predictors.WithVoteKey. Only the decision is compared, so Reasonings with a
+// different Thought but the same Action and Input, or the same FinalAnswer,
+// count as the same vote.
+func VoteKey[TOut any](r Reasoning[TOut]) string {
+	if r.Action != "" {
+		return fmt.Sprintf("action:%s:%s", normalizeToolName(r.Action), strings.TrimSpace(r.Input))
+	}
+	answer, err := json.Marshal(r.FinalAnswer)
+	if err != nil {
+		return fmt.Sprintf("final_answer:%#v", r.FinalAnswer)
+	}
+	return fmt.Sprintf("final_answer:%s", answer)
+}
+
 func normalizeToolName(name string) string {
 	name = strings.ToLower(name)
 	name = toolSpacePattern.ReplaceAllString(name, "-")
@@ -213,3 +213,50 @@ func TestReasoningPredictor(t *testing.T) {
 		})
 	}
 }
+
+func TestVoteKey(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name  string
+		a     Reasoning[FinalAnswer]
+		b     Reasoning[FinalAnswer]
+		equal bool
+	}{
+		{
+			name:  "same action with different thoughts",
+			a:     Reasoning[FinalAnswer]{Thought: "some-thought", Action: "List Columns", Input: "some-table"},
+			b:     Reasoning[FinalAnswer]{Thought: "other-thought", Action: "list-columns", Input: "some-table "},
+			equal: true,
+		},
+		{
+			name: "different inputs",
+			a:    Reasoning[FinalAnswer]{Thought: "some-thought", Action: "list-columns", Input: "some-table"},
+			b:    Reasoning[FinalAnswer]{Thought: "some-thought", Action: "list-columns", Input: "other-table"},
+		},
+		{
+			name:  "same final answer with different thoughts",
+			a:     Reasoning[FinalAnswer]{Thought: "some-thought", FinalAnswer: "some-answer"},
+			b:     Reasoning[FinalAnswer]{Thought: "other-thought", FinalAnswer: "some-answer"},
+			equal: true,
+		},
+		{
+			name: "action and final answer",
+			a:    Reasoning[FinalAnswer]{Thought: "some-thought", Action: "some-answer"},
+			b:    Reasoning[FinalAnswer]{Thought: "some-thought", FinalAnswer: "some-answer"},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if actual, expected := VoteKey(tc.a) == VoteKey(tc.b), tc.equal; actual != expected {
+				t.Fatalf("expected %v, got %v: %q and %q", expected, actual, VoteKey(tc.a), VoteKey(tc.b))
+			}
+		})
+	}
+}
@@ -0,0 +1,412 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// ErrBudgetExceeded is returned when the requests made with a context exceed
+// the limits of one of its budgets (see WithBudget). It is not retryable.
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// Price is the price of a model's tokens. The currency is up to the
+// PriceTable (e.g., USD).
+type Price struct {
+	// InputPerMillion is the price of a million tokens in the prompt.
+	InputPerMillion float64
+	// OutputPerMillion is the price of a million generated tokens.
+	OutputPerMillion float64
+}
+
+// PriceTable maps the models to their price. A key can be a prefix of the
+// model's name (e.g., "gemini-1.5-pro" for "gemini-1.5-pro-002"), in which
+// case the longest matching prefix is used.
+type PriceTable map[string]Price
+
+// Cost returns what the usage costs for the model. It is zero when the model
+// is not in the table.
+func (t PriceTable) Cost(model string, usage Usage) float64 {
+	var (
+		price Price
+		match string
+		found bool
+	)
+	for prefix, p := range t {
+		if strings.HasPrefix(model, prefix) && (!found || len(prefix) > len(match)) {
+			price, match, found = p, prefix, true
+		}
+	}
+	return (float64(usage.InputTokens)*price.InputPerMillion + float64(usage.OutputTokens)*price.OutputPerMillion) / 1e6
+}
+
+// Cost is the tokens used by requests and what they cost.
+type Cost struct {
+	Usage  Usage   `json:"usage"`
+	Amount float64 `json:"amount"`
+}
+
+func (c Cost) add(other Cost) Cost {
+	return Cost{
+		Usage: Usage{
+			InputTokens:  c.Usage.InputTokens + other.Usage.InputTokens,
+			OutputTokens: c.Usage.OutputTokens + other.Usage.OutputTokens,
+		},
+		Amount: c.Amount + other.Amount,
+	}
+}
+
+// BudgetOption is an option for NewBudget.
+type BudgetOption func(*Budget)
+
+// WithMaxCost limits the cost of the requests. It is unlimited by default.
+func WithMaxCost(amount float64) BudgetOption {
+	return func(b *Budget) {
+		b.maxCost = amount
+	}
+}
+
+// WithMaxTokens limits the tokens, both input and output, used by the
+// requests. It is unlimited by default.
+func WithMaxTokens(tokens int) BudgetOption {
+	return func(b *Budget) {
+		b.maxTokens = tokens
+	}
+}
+
+// Budget tracks the cost of the requests for each model and enforces limits
+// on the total. It is safe for concurrent use, so that a Budget can be shared
+// (e.g., by all the runs of a tenant).
+type Budget struct {
+	mu        sync.Mutex
+	maxCost   float64
+	maxTokens int
+	total     Cost
+	models    map[string]Cost
+}
+
+// NewBudget returns a Budget with the given limits.
+func NewBudget(opts ...BudgetOption) *Budget {
+	b := &Budget{models: map[string]Cost{}}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Add records the cost of a request to the model. It returns an error that
+// wraps ErrBudgetExceeded when the budget is exceeded, the cost is recorded
+// either way.
+func (b *Budget) Add(model string, cost Cost) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.total = b.total.add(cost)
+	b.models[model] = b.models[model].add(cost)
+	return b.err()
+}
+
+// Err returns an error that wraps ErrBudgetExceeded when the budget is
+// exceeded.
+func (b *Budget) Err() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.err()
+}
+
+func (b *Budget) err() error {
+	if tokens := b.total.Usage.InputTokens + b.total.Usage.OutputTokens; b.maxTokens > 0 && tokens > b.maxTokens {
+		return fmt.Errorf("%w: used %d tokens of %d", ErrBudgetExceeded, tokens, b.maxTokens)
+	}
+	if b.maxCost > 0 && b.total.Amount > b.maxCost {
+		return fmt.Errorf("%w: spent %g of %g", ErrBudgetExceeded, b.total.Amount, b.maxCost)
+	}
+	return nil
+}
+
+// Total returns the cost of all the requests.
+func (b *Budget) Total() Cost {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.total
+}
+
+// ByModel returns the cost of the requests for each model.
+func (b *Budget) ByModel() map[string]Cost {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	models := make(map[string]Cost, len(b.models))
+	for model, cost := range b.models {
+		models[model] = cost
+	}
+	return models
+}
+
+type budgetsKey struct{}
+
+// WithBudget returns a context that makes LLMs created with NewBudgetGuard
+// record their requests in the budget and fail once it is exceeded. Budgets
+// nest, so the requests are recorded in every budget of the context (e.g., one
+// for the tenant and one for the run).
+func WithBudget(ctx context.Context, b *Budget) context.Context {
+	parents := budgets(ctx)
+	bs := make([]*Budget, 0, len(parents)+1)
+	bs = append(bs, parents...)
+	bs = append(bs, b)
+	return context.WithValue(ctx, budgetsKey{}, bs)
+}
+
+func budgets(ctx context.Context) []*Budget {
+	bs, _ := ctx.Value(budgetsKey{}).([]*Budget)
+	return bs
+}
+
+// NewBudgetGuard returns an LLM that records the cost of each request in the
+// budgets of its context (see WithBudget), using the prices and the model read
+// from the params with the given function. A request fails with
+// ErrBudgetExceeded without being sent once a budget is exceeded. The request
+// that exceeds it returns its result along with the error, since it was paid
+// for. The tokens are estimated with EstimateTokens when
+// the LLM doesn't report its usage, unless the result was cached (see
+// NewCache), which is free. The candidates, function calling and multimodal
+// requests are forwarded, so that each is recorded as a single request. Use
+// NewChatBudgetGuard for a ChatLLM.
+func NewBudgetGuard[TParams any](llm LLM[TParams], prices PriceTable, model func(TParams) string) LLM[TParams] {
+	return budgetGuard[TParams]{
+		llm:    llm,
+		prices: prices,
+		model:  model,
+	}
+}
+
+type budgetGuard[TParams any] struct {
+	llm    LLM[TParams]
+	prices PriceTable
+	model  func(TParams) string
+}
+
+// Generate implements LLM.
+func (g budgetGuard[TParams]) Generate(ctx context.Context, prompt string, params TParams) (string, error) {
+	result, err := g.GenerateWithMetadata(ctx, prompt, params)
+	return result.Text, err
+}
+
+// GenerateWithMetadata implements MetadataLLM.
+func (g budgetGuard[TParams]) GenerateWithMetadata(ctx context.Context, prompt string, params TParams) (Result, error) {
+	if err := checkBudgets(ctx); err != nil {
+		return Result{}, err
+	}
+
+	result, err := GenerateWithMetadata(ctx, g.llm, prompt, params)
+	if err != nil {
+		return Result{}, err
+	}
+	return result, g.add(ctx, params, resultsUsage(prompt, result))
+}
+
+// GenerateCandidates implements CandidatesLLM.
+func (g budgetGuard[TParams]) GenerateCandidates(ctx context.Context, prompt string, params TParams, n int) ([]Result, error) {
+	if err := checkBudgets(ctx); err != nil {
+		return nil, err
+	}
+
+	results, err := GenerateCandidates(ctx, g.llm, prompt, params, n)
+	if err != nil {
+		return nil, err
+	}
+	return results, g.add(ctx, params, resultsUsage(prompt, results...))
+}
+
+// GenerateWithFunctions implements FunctionCallingLLM.
+func (g budgetGuard[TParams]) GenerateWithFunctions(ctx context.Context, prompt string, functions []FunctionDeclaration, params TParams) (Result, error) {
+	if err := checkBudgets(ctx); err != nil {
+		return Result{}, err
+	}
+
+	result, err := GenerateWithFunctions(ctx, g.llm, prompt, functions, params)
+	if err != nil {
+		return Result{}, err
+	}
+	return result, g.add(ctx, params, resultsUsage(prompt, result))
+}
+
+// GenerateMultimodal implements MultimodalLLM. Only the text parts are counted
+// when the tokens are estimated.
+func (g budgetGuard[TParams]) GenerateMultimodal(ctx context.Context, parts []Part, params TParams) (Result, error) {
+	if err := checkBudgets(ctx); err != nil {
+		return Result{}, err
+	}
+
+	result, err := GenerateMultimodal(ctx, g.llm, parts, params)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var prompt strings.Builder
+	for _, p := range parts {
+		prompt.WriteString(p.Text)
+	}
+	return result, g.add(ctx, params, resultsUsage(prompt.String(), result))
+}
+
+// GenerateStream implements StreamLLM. The cost is recorded once the stream
+// has been fully read or closed.
+func (g budgetGuard[TParams]) GenerateStream(ctx context.Context, prompt string, params TParams) (Stream, error) {
+	if err := checkBudgets(ctx); err != nil {
+		return nil, err
+	}
+
+	s, err := GenerateStream(ctx, g.llm, prompt, params)
+	if err != nil {
+		return nil, err
+	}
+	return &budgetStream[TParams]{
+		s:      s,
+		ctx:    ctx,
+		prompt: prompt,
+		params: params,
+		guard:  g,
+	}, nil
+}
+
+func (g budgetGuard[TParams]) add(ctx context.Context, params TParams, usage Usage) error {
+	var model string
+	if g.model != nil {
+		model = g.model(params)
+	}
+	cost := Cost{Usage: usage, Amount: g.prices.Cost(model, usage)}
+
+	var errs []error
+	for _, b := range budgets(ctx) {
+		if err := b.Add(model, cost); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NewChatBudgetGuard returns a ChatLLM that records the cost of each request
+// like NewBudgetGuard. A ChatLLM doesn't report its usage, so the tokens are
+// always estimated from the flattened conversation (see FlattenMessages).
+func NewChatBudgetGuard[TParams any](chat ChatLLM[TParams], prices PriceTable, model func(TParams) string) ChatLLM[TParams] {
+	return chatBudgetGuard[TParams]{
+		chat: chat,
+		guard: budgetGuard[TParams]{
+			prices: prices,
+			model:  model,
+		},
+	}
+}
+
+type chatBudgetGuard[TParams any] struct {
+	chat  ChatLLM[TParams]
+	guard budgetGuard[TParams]
+}
+
+// Chat implements ChatLLM.
+func (g chatBudgetGuard[TParams]) Chat(ctx context.Context, messages []Message, params TParams) (Message, error) {
+	if err := checkBudgets(ctx); err != nil {
+		return Message{}, err
+	}
+
+	m, err := g.chat.Chat(ctx, messages, params)
+	if err != nil {
+		return Message{}, err
+	}
+	return m, g.guard.add(ctx, params, estimateUsage(FlattenMessages(messages), m.Content))
+}
+
+func checkBudgets(ctx context.Context) error {
+	var errs []error
+	for _, b := range budgets(ctx) {
+		if err := b.Err(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// resultsUsage returns the tokens used for the results of a request. They are
+// estimated when the LLM doesn't report them, unless the results were cached.
+func resultsUsage(prompt string, results ...Result) Usage {
+	var (
+		usage  Usage
+		text   strings.Builder
+		cached = true
+	)
+	for _, r := range results {
+		usage.InputTokens += r.Usage.InputTokens
+		usage.OutputTokens += r.Usage.OutputTokens
+		text.WriteString(r.Text)
+		cached = cached && r.Cached
+	}
+	if usage == (Usage{}) && !cached {
+		return estimateUsage(prompt, text.String())
+	}
+	return usage
+}
+
+func estimateUsage(prompt, text string) Usage {
+	return Usage{
+		InputTokens:  EstimateTokens(prompt),
+		OutputTokens: EstimateTokens(text),
+	}
+}
+
+// budgetStream records the cost of the streamed response once it ends.
+type budgetStream[TParams any] struct {
+	s        Stream
+	ctx      context.Context
+	prompt   string
+	params   TParams
+	guard    budgetGuard[TParams]
+	b        strings.Builder
+	recorded bool
+}
+
+// Recv implements Stream.
+func (s *budgetStream[TParams]) Recv() (string, error) {
+	chunk, err := s.s.Recv()
+	if errors.Is(err, io.EOF) {
+		if e := s.record(); e != nil {
+			return "", e
+		}
+		return "", err
+	}
+	if err != nil {
+		return "", err
+	}
+	s.b.WriteString(chunk)
+	return chunk, nil
+}
+
+// Close implements Stream.
+func (s *budgetStream[TParams]) Close() error {
+	// The budget error is returned by the next request instead.
+	_ = s.record()
+	return s.s.Close()
+}
+
+func (s *budgetStream[TParams]) record() error {
+	if s.recorded {
+		return nil
+	}
+	s.recorded = true
+	return s.guard.add(s.ctx, s.params, estimateUsage(s.prompt, s.b.String()))
+}
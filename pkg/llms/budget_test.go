@@ -0,0 +1,315 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/go-react/pkg/llms"
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+)
+
+func TestPriceTable_Cost(t *testing.T) {
+	t.Parallel()
+
+	prices := llms.PriceTable{
+		"gemini":         {InputPerMillion: 1, OutputPerMillion: 1},
+		"gemini-1.5-pro": {InputPerMillion: 2, OutputPerMillion: 4},
+	}
+
+	testCases := []struct {
+		model    string
+		expected float64
+	}{
+		{model: "gemini-1.5-pro-002", expected: 10},
+		{model: "gemini-1.0-pro", expected: 3},
+		{model: "unknown-model", expected: 0},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.model, func(t *testing.T) {
+			t.Parallel()
+
+			usage := llms.Usage{InputTokens: 1_000_000, OutputTokens: 2_000_000}
+			if actual, expected := prices.Cost(tc.model, usage), tc.expected; actual != expected {
+				t.Fatalf("expected %v, got %v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestBudget(t *testing.T) {
+	t.Parallel()
+
+	b := llms.NewBudget(llms.WithMaxCost(1))
+	if err := b.Add("model-a", llms.Cost{Usage: llms.Usage{InputTokens: 1}, Amount: 0.5}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := b.Add("model-b", llms.Cost{Usage: llms.Usage{OutputTokens: 2}, Amount: 0.5}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := b.Add("model-a", llms.Cost{Usage: llms.Usage{InputTokens: 3}, Amount: 0.5}); !errors.Is(err, llms.ErrBudgetExceeded) {
+		t.Fatalf("expected %v, got %v", llms.ErrBudgetExceeded, err)
+	}
+	if err := b.Err(); !errors.Is(err, llms.ErrBudgetExceeded) {
+		t.Fatalf("expected %v, got %v", llms.ErrBudgetExceeded, err)
+	}
+
+	expected := llms.Cost{Usage: llms.Usage{InputTokens: 4, OutputTokens: 2}, Amount: 1.5}
+	if actual := b.Total(); actual != expected {
+		t.Fatalf("expected %+v, got %+v", expected, actual)
+	}
+	expected = llms.Cost{Usage: llms.Usage{InputTokens: 4}, Amount: 1}
+	if actual := b.ByModel()["model-a"]; actual != expected {
+		t.Fatalf("expected %+v, got %+v", expected, actual)
+	}
+}
+
+func TestBudgetGuard(t *testing.T) {
+	t.Parallel()
+
+	fake := &llmstesting.Fake[string]{
+		Results: map[string]llms.Result{
+			"some-prompt": {Text: "some-output", Usage: llms.Usage{InputTokens: 10, OutputTokens: 10}},
+		},
+	}
+	llm := llms.NewBudgetGuard[string](fake, llms.PriceTable{
+		"some-model": {InputPerMillion: 1e6, OutputPerMillion: 1e6},
+	}, func(model string) string { return model })
+
+	tenant := llms.NewBudget(llms.WithMaxCost(30))
+	run := llms.NewBudget(llms.WithMaxCost(25))
+	ctx := llms.WithBudget(llms.WithBudget(context.Background(), tenant), run)
+
+	if _, err := llm.Generate(ctx, "some-prompt", "some-model"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// The request that exceeds the run's budget fails, but still returns its
+	// result.
+	text, err := llm.Generate(ctx, "some-prompt", "some-model")
+	if !errors.Is(err, llms.ErrBudgetExceeded) {
+		t.Fatalf("expected %v, got %v", llms.ErrBudgetExceeded, err)
+	}
+	if actual, expected := text, "some-output"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+
+	// Once exceeded, the requests are not sent.
+	if _, err := llm.Generate(ctx, "some-prompt", "some-model"); !errors.Is(err, llms.ErrBudgetExceeded) {
+		t.Fatalf("expected %v, got %v", llms.ErrBudgetExceeded, err)
+	}
+	if actual, expected := len(fake.Prompts), 2; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+
+	// Both budgets recorded the requests.
+	for _, b := range []*llms.Budget{tenant, run} {
+		if actual, expected := b.Total().Amount, 40.0; actual != expected {
+			t.Fatalf("expected %v, got %v", expected, actual)
+		}
+	}
+
+	// The tenant's budget can still be used in another run.
+	if _, err := llm.Generate(llms.WithBudget(context.Background(), tenant), "some-prompt", "some-model"); !errors.Is(err, llms.ErrBudgetExceeded) {
+		t.Fatalf("expected %v, got %v", llms.ErrBudgetExceeded, err)
+	}
+}
+
+func TestBudgetGuard_estimate(t *testing.T) {
+	t.Parallel()
+
+	llm := llms.NewBudgetGuard[int](echo{}, nil, nil)
+	b := llms.NewBudget()
+	ctx := llms.WithBudget(context.Background(), b)
+
+	if _, err := llm.Generate(ctx, "some-prompt", 1); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	s, err := llms.GenerateStream[int](ctx, llm, "some-prompt", 1)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for {
+		if _, err := s.Recv(); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// The LLM doesn't report its usage, so the tokens are estimated for both
+	// the prompt and the echoed output.
+	expected := llms.Cost{Usage: llms.Usage{InputTokens: 6, OutputTokens: 6}}
+	if actual := b.Total(); actual != expected {
+		t.Fatalf("expected %+v, got %+v", expected, actual)
+	}
+}
+
+func TestBudgetGuard_requests(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		generate func(ctx context.Context, llm llms.LLM[int]) error
+		prompts  int
+		expected llms.Usage
+	}{
+		{
+			name: "candidates",
+			generate: func(ctx context.Context, llm llms.LLM[int]) error {
+				_, err := llms.GenerateCandidates(ctx, llm, "candidates-prompt", 1, 3)
+				return err
+			},
+			// The candidates are a single request.
+			prompts:  1,
+			expected: llms.Usage{InputTokens: 10, OutputTokens: 30},
+		},
+		{
+			name: "multimodal",
+			generate: func(ctx context.Context, llm llms.LLM[int]) error {
+				_, err := llms.GenerateMultimodal(ctx, llm, []llms.Part{
+					llms.TextPart("some-prompt"),
+					llms.DataPart("image/png", []byte("some-image")),
+				}, 1)
+				return err
+			},
+			prompts:  1,
+			expected: llms.Usage{InputTokens: 10, OutputTokens: 10},
+		},
+		{
+			name: "functions",
+			generate: func(ctx context.Context, llm llms.LLM[int]) error {
+				_, err := llms.GenerateWithFunctions(ctx, llm, "some-prompt", []llms.FunctionDeclaration{{Name: "some-function"}}, 1)
+				return err
+			},
+			prompts:  1,
+			expected: llms.Usage{InputTokens: 10, OutputTokens: 10},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := &llmstesting.Fake[int]{
+				Results: map[string]llms.Result{
+					"some-prompt": {Text: "some-output", Usage: llms.Usage{InputTokens: 10, OutputTokens: 10}},
+				},
+				Candidates: map[string][]llms.Result{
+					"candidates-prompt": {
+						{Text: "a", Usage: llms.Usage{InputTokens: 10, OutputTokens: 30}},
+						{Text: "b"},
+						{Text: "c"},
+					},
+				},
+			}
+			llm := llms.NewBudgetGuard[int](fake, nil, nil)
+			b := llms.NewBudget()
+			if err := tc.generate(llms.WithBudget(context.Background(), b), llm); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if actual, expected := len(fake.Prompts), tc.prompts; actual != expected {
+				t.Fatalf("expected %d, got %d", expected, actual)
+			}
+			if actual, expected := b.Total().Usage, tc.expected; actual != expected {
+				t.Fatalf("expected %+v, got %+v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestChatBudgetGuard(t *testing.T) {
+	t.Parallel()
+
+	fake := &llmstesting.FakeChat[string]{AlwaysText: "some-output"}
+	chat := llms.NewChatBudgetGuard[string](fake, llms.PriceTable{
+		"some-model": {InputPerMillion: 1e6, OutputPerMillion: 1e6},
+	}, func(model string) string { return model })
+
+	b := llms.NewBudget(llms.WithMaxCost(20))
+	ctx := llms.WithBudget(context.Background(), b)
+	messages := []llms.Message{
+		{Role: llms.RoleSystem, Content: "some-system"},
+		{Role: llms.RoleUser, Content: "some-prompt"},
+	}
+
+	if _, err := chat.Chat(ctx, messages, "some-model"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// The tokens are estimated for the flattened conversation and the reply.
+	expected := llms.Usage{
+		InputTokens:  llms.EstimateTokens(llms.FlattenMessages(messages)),
+		OutputTokens: llms.EstimateTokens("some-output"),
+	}
+	if actual := b.Total().Usage; actual != expected {
+		t.Fatalf("expected %+v, got %+v", expected, actual)
+	}
+
+	// The request that exceeds the budget fails with its reply, and the next
+	// one is not sent.
+	m, err := chat.Chat(ctx, messages, "some-model")
+	if !errors.Is(err, llms.ErrBudgetExceeded) {
+		t.Fatalf("expected %v, got %v", llms.ErrBudgetExceeded, err)
+	}
+	if actual, expected := m.Content, "some-output"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+	if _, err := chat.Chat(ctx, messages, "some-model"); !errors.Is(err, llms.ErrBudgetExceeded) {
+		t.Fatalf("expected %v, got %v", llms.ErrBudgetExceeded, err)
+	}
+	if actual, expected := len(fake.Messages), 2; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+}
+
+func TestBudgetGuard_cached(t *testing.T) {
+	t.Parallel()
+
+	fake := &llmstesting.Fake[int]{
+		Results: map[string]llms.Result{
+			"some-prompt": {Text: "some-response", Usage: llms.Usage{InputTokens: 3, OutputTokens: 5}},
+		},
+	}
+	prices := llms.PriceTable{"": {InputPerMillion: 1e6, OutputPerMillion: 1e6}}
+	llm := llms.NewBudgetGuard[int](llms.NewCache[int](fake, llms.NewMemoryCacheStore(10)), prices, nil)
+
+	b := llms.NewBudget()
+	ctx := llms.WithBudget(context.Background(), b)
+	for i := 0; i < 3; i++ {
+		if _, err := llm.Generate(ctx, "some-prompt", 1); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	// Only the request that missed the cache is charged.
+	expected := llms.Cost{Usage: llms.Usage{InputTokens: 3, OutputTokens: 5}, Amount: 8}
+	if actual := b.Total(); actual != expected {
+		t.Fatalf("expected %+v, got %+v", expected, actual)
+	}
+}
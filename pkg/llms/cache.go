@@ -0,0 +1,269 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// CacheEntry is a cached response.
+type CacheEntry struct {
+	Result Result `json:"result"`
+	// Candidates are the results of a GenerateCandidates request.
+	Candidates []Result `json:"candidates,omitempty"`
+	// Expires is when the entry should no longer be used. It is zero when the
+	// entry does not expire.
+	Expires time.Time `json:"expires,omitempty"`
+}
+
+// CacheStore stores the responses cached by NewCache. It must be safe for
+// concurrent use.
+type CacheStore interface {
+	// Get returns the entry for the key and whether it was found.
+	Get(ctx context.Context, key string) (CacheEntry, bool, error)
+	// Set stores the entry for the key.
+	Set(ctx context.Context, key string, entry CacheEntry) error
+}
+
+// CacheOption is an option for NewCache.
+type CacheOption func(*cacheConfig)
+
+type cacheConfig struct {
+	ttl time.Duration
+	now func() time.Time
+}
+
+// WithCacheTTL sets how long responses are cached for. By default, they don't
+// expire.
+func WithCacheTTL(ttl time.Duration) CacheOption {
+	return func(c *cacheConfig) {
+		c.ttl = ttl
+	}
+}
+
+type bypassCacheKey struct{}
+
+// WithoutCache returns a context that makes LLMs created with NewCache skip the
+// cache. The response is neither read from nor written to the cache.
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassCacheKey{}, true)
+}
+
+func cacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassCacheKey{}).(bool)
+	return bypass
+}
+
+// NewCache returns an LLM that caches the responses from the given LLM in the
+// store. Responses are keyed by the prompt and the JSON encoding of the
+// params, so the params must encode to the same JSON whenever they are equal.
+// Errors are not cached. Writing to the cache is best effort: when the store
+// fails, the response is still returned. A cached result is returned with
+// Cached set and a zero Usage, so that the wrappers around the cache don't
+// count it as spent tokens.
+// The candidates of a GenerateCandidates request are cached together, under a
+// key that includes their number.
+func NewCache[TParams any](llm LLM[TParams], store CacheStore, opts ...CacheOption) LLM[TParams] {
+	config := cacheConfig{now: time.Now}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return cache[TParams]{
+		llm:    llm,
+		store:  store,
+		config: config,
+	}
+}
+
+type cache[TParams any] struct {
+	llm    LLM[TParams]
+	store  CacheStore
+	config cacheConfig
+}
+
+// Generate implements LLM.
+func (c cache[TParams]) Generate(ctx context.Context, prompt string, params TParams) (string, error) {
+	result, err := c.GenerateWithMetadata(ctx, prompt, params)
+	if err != nil {
+		return "", err
+	}
+	return result.Text, nil
+}
+
+// GenerateWithMetadata implements MetadataLLM.
+func (c cache[TParams]) GenerateWithMetadata(ctx context.Context, prompt string, params TParams) (Result, error) {
+	if cacheBypassed(ctx) {
+		return GenerateWithMetadata(ctx, c.llm, prompt, params)
+	}
+
+	key, err := cacheKey(prompt, params, 0)
+	if err != nil {
+		return Result{}, err
+	}
+	entry, ok, err := c.get(ctx, key)
+	if err != nil {
+		return Result{}, err
+	}
+	if ok {
+		return cachedResult(entry.Result), nil
+	}
+
+	result, err := GenerateWithMetadata(ctx, c.llm, prompt, params)
+	if err != nil {
+		return Result{}, err
+	}
+	c.set(ctx, key, CacheEntry{Result: result})
+	return result, nil
+}
+
+// GenerateCandidates implements CandidatesLLM.
+func (c cache[TParams]) GenerateCandidates(ctx context.Context, prompt string, params TParams, n int) ([]Result, error) {
+	if cacheBypassed(ctx) {
+		return GenerateCandidates(ctx, c.llm, prompt, params, n)
+	}
+
+	key, err := cacheKey(prompt, params, n)
+	if err != nil {
+		return nil, err
+	}
+	entry, ok, err := c.get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		results := make([]Result, 0, len(entry.Candidates))
+		for _, result := range entry.Candidates {
+			results = append(results, cachedResult(result))
+		}
+		return results, nil
+	}
+
+	results, err := GenerateCandidates(ctx, c.llm, prompt, params, n)
+	if err != nil {
+		return nil, err
+	}
+	c.set(ctx, key, CacheEntry{Candidates: results})
+	return results, nil
+}
+
+// GenerateStream implements StreamLLM. A cached response is returned as a
+// single chunk. Otherwise, the response is cached once it has been fully
+// read.
+func (c cache[TParams]) GenerateStream(ctx context.Context, prompt string, params TParams) (Stream, error) {
+	if cacheBypassed(ctx) {
+		return GenerateStream(ctx, c.llm, prompt, params)
+	}
+
+	key, err := cacheKey(prompt, params, 0)
+	if err != nil {
+		return nil, err
+	}
+	entry, ok, err := c.get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return NewStaticStream(entry.Result.Text), nil
+	}
+
+	s, err := GenerateStream(ctx, c.llm, prompt, params)
+	if err != nil {
+		return nil, err
+	}
+	return &cacheStream[TParams]{
+		s:     s,
+		ctx:   ctx,
+		key:   key,
+		cache: c,
+	}, nil
+}
+
+func (c cache[TParams]) get(ctx context.Context, key string) (CacheEntry, bool, error) {
+	entry, ok, err := c.store.Get(ctx, key)
+	if err != nil {
+		return CacheEntry{}, false, fmt.Errorf("failed to read from cache: %v", err)
+	}
+	if !ok || (!entry.Expires.IsZero() && !c.config.now().Before(entry.Expires)) {
+		return CacheEntry{}, false, nil
+	}
+	return entry, true, nil
+}
+
+// set stores the entry. A failure is ignored, since the response can be
+// returned without being cached.
+func (c cache[TParams]) set(ctx context.Context, key string, entry CacheEntry) {
+	if c.config.ttl > 0 {
+		entry.Expires = c.config.now().Add(c.config.ttl)
+	}
+	_ = c.store.Set(ctx, key, entry)
+}
+
+// cachedResult returns a result read from the cache.
+func cachedResult(result Result) Result {
+	result.Usage = Usage{}
+	result.Cached = true
+	return result
+}
+
+// cacheKey returns a hash of the prompt, params and number of candidates. The
+// number is zero for a single result.
+func cacheKey[TParams any](prompt string, params TParams, n int) (string, error) {
+	data, err := json.Marshal(struct {
+		Prompt     string  `json:"prompt"`
+		Params     TParams `json:"params"`
+		Candidates int     `json:"candidates,omitempty"`
+	}{prompt, params, n})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cache key: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// cacheStream caches the response once the stream has been fully read.
+type cacheStream[TParams any] struct {
+	s     Stream
+	ctx   context.Context
+	key   string
+	cache cache[TParams]
+	b     strings.Builder
+}
+
+// Recv implements Stream.
+func (s *cacheStream[TParams]) Recv() (string, error) {
+	chunk, err := s.s.Recv()
+	if errors.Is(err, io.EOF) {
+		s.cache.set(s.ctx, s.key, CacheEntry{Result: Result{Text: s.b.String()}})
+		return "", err
+	}
+	if err != nil {
+		return "", err
+	}
+	s.b.WriteString(chunk)
+	return chunk, nil
+}
+
+// Close implements Stream.
+func (s *cacheStream[TParams]) Close() error {
+	return s.s.Close()
+}
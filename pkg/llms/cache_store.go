@@ -0,0 +1,145 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// NewMemoryCacheStore returns a CacheStore that keeps up to size entries in
+// memory. The least recently used entry is evicted when it is full.
+func NewMemoryCacheStore(size int) CacheStore {
+	if size < 1 {
+		panic("size must be at least 1")
+	}
+	return &memoryCacheStore{
+		size:    size,
+		order:   list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+type memoryCacheStore struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type memoryCacheItem struct {
+	key   string
+	entry CacheEntry
+}
+
+// Get implements CacheStore.
+func (s *memoryCacheStore) Get(ctx context.Context, key string) (CacheEntry, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		return CacheEntry{}, false, nil
+	}
+	s.order.MoveToFront(e)
+	return e.Value.(*memoryCacheItem).entry, true, nil
+}
+
+// Set implements CacheStore.
+func (s *memoryCacheStore) Set(ctx context.Context, key string, entry CacheEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok {
+		e.Value.(*memoryCacheItem).entry = entry
+		s.order.MoveToFront(e)
+		return nil
+	}
+
+	s.entries[key] = s.order.PushFront(&memoryCacheItem{key: key, entry: entry})
+	if s.order.Len() > s.size {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*memoryCacheItem).key)
+	}
+	return nil
+}
+
+// NewDiskCacheStore returns a CacheStore that keeps each entry in a JSON file
+// in the directory, so that the cache persists between runs. The directory is
+// created if it doesn't exist.
+func NewDiskCacheStore(dir string) (CacheStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %v", err)
+	}
+	return diskCacheStore{dir: dir}, nil
+}
+
+type diskCacheStore struct {
+	dir string
+}
+
+// Get implements CacheStore. Entries that can't be decoded (e.g., from a
+// partial write) are treated as missing.
+func (s diskCacheStore) Get(ctx context.Context, key string) (CacheEntry, bool, error) {
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return CacheEntry{}, false, nil
+	}
+	if err != nil {
+		return CacheEntry{}, false, err
+	}
+
+	var entry CacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return CacheEntry{}, false, nil
+	}
+	return entry, true, nil
+}
+
+// Set implements CacheStore. The entry is written to a temporary file first
+// so that readers never see a partial entry.
+func (s diskCacheStore) Set(ctx context.Context, key string, entry CacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(s.dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.path(key))
+}
+
+func (s diskCacheStore) path(key string) string {
+	return filepath.Join(s.dir, key+".json")
+}
@@ -0,0 +1,402 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-react/pkg/llms"
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+)
+
+// mapStore is a CacheStore that lets the tests inspect the entries.
+type mapStore struct {
+	mu      sync.Mutex
+	entries map[string]llms.CacheEntry
+}
+
+func (s *mapStore) Get(ctx context.Context, key string) (llms.CacheEntry, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	return e, ok, nil
+}
+
+func (s *mapStore) Set(ctx context.Context, key string, entry llms.CacheEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.entries == nil {
+		s.entries = map[string]llms.CacheEntry{}
+	}
+	s.entries[key] = entry
+	return nil
+}
+
+func TestCache(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		calls    func(ctx context.Context, llm llms.LLM[int]) error
+		expected int
+	}{
+		{
+			name: "same prompt and params",
+			calls: func(ctx context.Context, llm llms.LLM[int]) error {
+				for i := 0; i < 3; i++ {
+					if _, err := llm.Generate(ctx, "some-prompt", 1); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			expected: 1,
+		},
+		{
+			name: "different params",
+			calls: func(ctx context.Context, llm llms.LLM[int]) error {
+				for i := 0; i < 3; i++ {
+					if _, err := llm.Generate(ctx, "some-prompt", i); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			expected: 3,
+		},
+		{
+			name: "bypassed",
+			calls: func(ctx context.Context, llm llms.LLM[int]) error {
+				for i := 0; i < 3; i++ {
+					if _, err := llm.Generate(llms.WithoutCache(ctx), "some-prompt", 1); err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+			expected: 3,
+		},
+		{
+			name: "streamed",
+			calls: func(ctx context.Context, llm llms.LLM[int]) error {
+				for i := 0; i < 3; i++ {
+					s, err := llms.GenerateStream(ctx, llm, "some-prompt", 1)
+					if err != nil {
+						return err
+					}
+					resp, err := llms.ReadStream(s)
+					if err != nil {
+						return err
+					}
+					if resp != "some-response" {
+						return errors.New("unexpected response: " + resp)
+					}
+				}
+				return nil
+			},
+			expected: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := &llmstesting.Fake[int]{AlwaysText: "some-response"}
+			llm := llms.NewCache[int](fake, llms.NewMemoryCacheStore(10))
+			if err := tc.calls(context.Background(), llm); err != nil {
+				t.Fatal(err)
+			}
+			if actual, expected := len(fake.Prompts), tc.expected; actual != expected {
+				t.Fatalf("expected %d, got %d", expected, actual)
+			}
+		})
+	}
+}
+
+func TestCache_errors(t *testing.T) {
+	t.Parallel()
+
+	fake := &llmstesting.Fake[int]{Err: errors.New("some-error")}
+	store := &mapStore{}
+	llm := llms.NewCache[int](fake, store)
+
+	for i := 0; i < 2; i++ {
+		if _, err := llm.Generate(context.Background(), "some-prompt", 1); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+	if actual, expected := len(fake.Prompts), 2; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+	if actual, expected := len(store.entries), 0; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+}
+
+// failingStore is a CacheStore whose writes fail.
+type failingStore struct{}
+
+func (failingStore) Get(ctx context.Context, key string) (llms.CacheEntry, bool, error) {
+	return llms.CacheEntry{}, false, nil
+}
+
+func (failingStore) Set(ctx context.Context, key string, entry llms.CacheEntry) error {
+	return errors.New("some-error")
+}
+
+func TestCache_setErrors(t *testing.T) {
+	t.Parallel()
+
+	fake := &llmstesting.Fake[int]{AlwaysText: "some-response"}
+	llm := llms.NewCache[int](fake, failingStore{})
+	ctx := context.Background()
+
+	// The responses are returned even though they can't be cached.
+	if actual, err := llm.Generate(ctx, "some-prompt", 1); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	} else if expected := "some-response"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+	results, err := llms.GenerateCandidates(ctx, llm, "some-prompt", 1, 2)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if actual, expected := len(results), 2; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+	s, err := llms.GenerateStream(ctx, llm, "some-prompt", 1)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer s.Close()
+	var text strings.Builder
+	for {
+		chunk, err := s.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		text.WriteString(chunk)
+	}
+	if actual, expected := text.String(), "some-response"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestCache_ttl(t *testing.T) {
+	t.Parallel()
+
+	fake := &llmstesting.Fake[int]{AlwaysText: "some-response"}
+	store := &mapStore{}
+	llm := llms.NewCache[int](fake, store, llms.WithCacheTTL(time.Hour))
+
+	if _, err := llm.Generate(context.Background(), "some-prompt", 1); err != nil {
+		t.Fatal(err)
+	}
+	for key, entry := range store.entries {
+		if actual, expected := time.Until(entry.Expires).Round(time.Minute), time.Hour; actual != expected {
+			t.Fatalf("expected %v, got %v", expected, actual)
+		}
+		// Expire the entry.
+		entry.Expires = time.Now().Add(-time.Second)
+		store.entries[key] = entry
+	}
+
+	if _, err := llm.Generate(context.Background(), "some-prompt", 1); err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := len(fake.Prompts), 2; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+}
+
+func TestCache_metadata(t *testing.T) {
+	t.Parallel()
+
+	fake := &llmstesting.Fake[int]{
+		Results: map[string]llms.Result{
+			"some-prompt": {
+				Text:         "some-response",
+				Usage:        llms.Usage{InputTokens: 3, OutputTokens: 5},
+				FinishReason: llms.FinishReasonStop,
+			},
+		},
+	}
+	llm := llms.NewCache[int](fake, llms.NewMemoryCacheStore(10))
+
+	// The cached result has no usage since no tokens were used for it.
+	for _, expected := range []llms.Result{
+		{
+			Text:         "some-response",
+			Usage:        llms.Usage{InputTokens: 3, OutputTokens: 5},
+			FinishReason: llms.FinishReasonStop,
+		},
+		{
+			Text:         "some-response",
+			FinishReason: llms.FinishReasonStop,
+			Cached:       true,
+		},
+	} {
+		actual, err := llms.GenerateWithMetadata(context.Background(), llm, "some-prompt", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("expected %+v, got %+v", expected, actual)
+		}
+	}
+	if actual, expected := len(fake.Prompts), 1; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+}
+
+func TestCache_candidates(t *testing.T) {
+	t.Parallel()
+
+	fake := &llmstesting.Fake[int]{
+		Candidates: map[string][]llms.Result{
+			"some-prompt": {
+				{Text: "a", Usage: llms.Usage{InputTokens: 3, OutputTokens: 5}},
+				{Text: "b"},
+				{Text: "c"},
+			},
+		},
+	}
+	llm := llms.NewCache[int](fake, llms.NewMemoryCacheStore(10))
+
+	// The candidates are cached together, so they are not replaced by the
+	// single cached result.
+	if _, err := llm.Generate(context.Background(), "some-prompt", 1); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		results, err := llms.GenerateCandidates(context.Background(), llm, "some-prompt", 1, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var texts []string
+		for _, result := range results {
+			texts = append(texts, result.Text)
+			if actual, expected := result.Cached, i > 0; actual != expected {
+				t.Fatalf("expected cached %v, got %v", expected, actual)
+			}
+		}
+		if actual, expected := strings.Join(texts, ","), "a,b,c"; actual != expected {
+			t.Fatalf("expected %q, got %q", expected, actual)
+		}
+		if i > 0 && results[0].Usage != (llms.Usage{}) {
+			t.Fatalf("expected no usage, got %+v", results[0].Usage)
+		}
+	}
+	// Asking for a different number of candidates is a different request.
+	if _, err := llms.GenerateCandidates(context.Background(), llm, "some-prompt", 1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := len(fake.Prompts), 3; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+}
+
+func TestCache_logger(t *testing.T) {
+	t.Parallel()
+
+	fake := &llmstesting.Fake[int]{AlwaysText: "some-response"}
+	var inner, outer bytes.Buffer
+	llm := llms.NewLogger[int](llms.NewCache[int](llms.NewLogger[int](fake, &inner), llms.NewMemoryCacheStore(10)), &outer)
+
+	for i := 0; i < 3; i++ {
+		if _, err := llm.Generate(context.Background(), "some-prompt", 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The outer logger sees every call while the inner one only sees misses.
+	if actual, expected := strings.Count(outer.String(), "\n"), 3; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+	if actual, expected := strings.Count(inner.String(), "\n"), 1; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+}
+
+func TestMemoryCacheStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := llms.NewMemoryCacheStore(2)
+	for _, key := range []string{"a", "b"} {
+		if err := store.Set(ctx, key, llms.CacheEntry{Result: llms.Result{Text: key}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Use a so that b is the least recently used.
+	if _, ok, _ := store.Get(ctx, "a"); !ok {
+		t.Fatal("expected a to be found")
+	}
+	if err := store.Set(ctx, "c", llms.CacheEntry{Result: llms.Result{Text: "c"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, expected := range map[string]bool{"a": true, "b": false, "c": true} {
+		entry, actual, err := store.Get(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != expected {
+			t.Fatalf("expected %q to be found: %v, got %v", key, expected, actual)
+		}
+		if actual && entry.Result.Text != key {
+			t.Fatalf("expected %q, got %q", key, entry.Result.Text)
+		}
+	}
+}
+
+func TestDiskCacheStore(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	fake := &llmstesting.Fake[int]{AlwaysText: "some-response"}
+
+	// Use a new store each time to make sure the entries are persisted.
+	for i := 0; i < 2; i++ {
+		store, err := llms.NewDiskCacheStore(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := llms.NewCache[int](fake, store).Generate(context.Background(), "some-prompt", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual, expected := resp, "some-response"; actual != expected {
+			t.Fatalf("expected %q, got %q", expected, actual)
+		}
+	}
+	if actual, expected := len(fake.Prompts), 1; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+}
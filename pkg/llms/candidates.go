@@ -0,0 +1,46 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms
+
+import "context"
+
+// CandidatesLLM is an LLM that can generate several candidate responses in a
+// single request.
+type CandidatesLLM[TParams any] interface {
+	LLM[TParams]
+	// GenerateCandidates generates n candidate responses from the given prompt
+	// and params. The LLM may return fewer candidates (e.g., when some were
+	// blocked).
+	GenerateCandidates(ctx context.Context, prompt string, params TParams, n int) ([]Result, error)
+}
+
+// GenerateCandidates generates n candidates in a single request if the LLM
+// implements CandidatesLLM. Otherwise, it falls back to calling the LLM n
+// times.
+func GenerateCandidates[TParams any](ctx context.Context, llm LLM[TParams], prompt string, params TParams, n int) ([]Result, error) {
+	if c, ok := llm.(CandidatesLLM[TParams]); ok {
+		return c.GenerateCandidates(ctx, prompt, params, n)
+	}
+
+	results := make([]Result, 0, n)
+	for i := 0; i < n; i++ {
+		result, err := GenerateWithMetadata(ctx, llm, prompt, params)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
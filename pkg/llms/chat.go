@@ -0,0 +1,122 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Role is the author of a Message.
+type Role string
+
+const (
+	// RoleSystem is used for instructions that frame the whole conversation.
+	RoleSystem Role = "system"
+	// RoleUser is used for messages written by the user.
+	RoleUser Role = "user"
+	// RoleAssistant is used for messages written by the model.
+	RoleAssistant Role = "assistant"
+	// RoleTool is used for the output of a tool the model asked to run.
+	RoleTool Role = "tool"
+)
+
+// Message is a single role-tagged message in a conversation.
+type Message struct {
+	Role    Role   `json:"role"`
+	Content string `json:"content"`
+	// Name optionally names the tool that produced a RoleTool message.
+	Name string `json:"name,omitempty"`
+}
+
+// ChatLLM is a language model that is prompted with a conversation instead of
+// a single flat prompt.
+type ChatLLM[TParams any] interface {
+	// Chat generates the next message in the given conversation.
+	Chat(ctx context.Context, messages []Message, params TParams) (Message, error)
+}
+
+// NewChatAdapter returns a ChatLLM that flattens the conversation into a
+// single prompt for the given LLM. It is useful for using completion models
+// with code that expects a ChatLLM.
+func NewChatAdapter[TParams any](llm LLM[TParams]) ChatLLM[TParams] {
+	return chatAdapter[TParams]{llm: llm}
+}
+
+type chatAdapter[TParams any] struct {
+	llm LLM[TParams]
+}
+
+// Chat implements ChatLLM.
+func (a chatAdapter[TParams]) Chat(ctx context.Context, messages []Message, params TParams) (Message, error) {
+	resp, err := a.llm.Generate(ctx, FlattenMessages(messages), params)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{
+		Role:    RoleAssistant,
+		Content: strings.TrimSpace(resp),
+	}, nil
+}
+
+// FlattenMessages converts a conversation into a single transcript that can
+// be sent to a completion model. System messages are written as-is while the
+// other messages are prefixed by their role. The transcript ends with an
+// open assistant turn for the model to complete.
+func FlattenMessages(messages []Message) string {
+	var b strings.Builder
+	for _, m := range messages {
+		switch m.Role {
+		case RoleSystem:
+			b.WriteString(m.Content)
+		case RoleUser:
+			fmt.Fprintf(&b, "User: %s", m.Content)
+		case RoleAssistant:
+			fmt.Fprintf(&b, "Assistant: %s", m.Content)
+		case RoleTool:
+			if m.Name != "" {
+				fmt.Fprintf(&b, "Tool (%s): %s", m.Name, m.Content)
+			} else {
+				fmt.Fprintf(&b, "Tool: %s", m.Content)
+			}
+		default:
+			fmt.Fprintf(&b, "%s: %s", m.Role, m.Content)
+		}
+		b.WriteString("\n\n")
+	}
+	b.WriteString("Assistant:")
+	return b.String()
+}
+
+// NewCompletionAdapter returns an LLM that sends each prompt to the given
+// ChatLLM as a single user message. It is useful for using chat models with
+// code that expects an LLM (e.g., predictors.New).
+func NewCompletionAdapter[TParams any](chat ChatLLM[TParams]) LLM[TParams] {
+	return completionAdapter[TParams]{chat: chat}
+}
+
+type completionAdapter[TParams any] struct {
+	chat ChatLLM[TParams]
+}
+
+// Generate implements LLM.
+func (a completionAdapter[TParams]) Generate(ctx context.Context, prompt string, params TParams) (string, error) {
+	m, err := a.chat.Chat(ctx, []Message{{Role: RoleUser, Content: prompt}}, params)
+	if err != nil {
+		return "", err
+	}
+	return m.Content, nil
+}
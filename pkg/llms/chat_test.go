@@ -0,0 +1,103 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-react/pkg/llms"
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+)
+
+func TestChatAdapter(t *testing.T) {
+	t.Parallel()
+	var fake llmstesting.Fake[int]
+	fake.AlwaysText = " some-response "
+
+	chat := llms.NewChatAdapter[int](&fake)
+	resp, err := chat.Chat(context.Background(), []llms.Message{
+		{Role: llms.RoleSystem, Content: "some-instructions"},
+		{Role: llms.RoleUser, Content: "some-question"},
+		{Role: llms.RoleAssistant, Content: "some-answer"},
+		{Role: llms.RoleTool, Name: "some-tool", Content: "some-observation"},
+	}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedPrompt := "some-instructions\n\n" +
+		"User: some-question\n\n" +
+		"Assistant: some-answer\n\n" +
+		"Tool (some-tool): some-observation\n\n" +
+		"Assistant:"
+	if actual, expected := fake.Prompts[0], expectedPrompt; actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+	if actual, expected := fake.Params[0], 1; actual != expected {
+		t.Errorf("expected %d, got %d", expected, actual)
+	}
+	if actual, expected := resp.Role, llms.RoleAssistant; actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+	if actual, expected := resp.Content, "some-response"; actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestChatAdapter_error(t *testing.T) {
+	t.Parallel()
+	var fake llmstesting.Fake[int]
+	fake.Err = errors.New("some-error")
+
+	chat := llms.NewChatAdapter[int](&fake)
+	if _, err := chat.Chat(context.Background(), nil, 1); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestCompletionAdapter(t *testing.T) {
+	t.Parallel()
+	var fake llmstesting.FakeChat[int]
+	fake.AlwaysText = "some-response"
+
+	llm := llms.NewCompletionAdapter[int](&fake)
+	resp, err := llm.Generate(context.Background(), "some-prompt", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if actual, expected := len(fake.Messages[0]), 1; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+	if actual, expected := fake.Messages[0][0], (llms.Message{Role: llms.RoleUser, Content: "some-prompt"}); actual != expected {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+	if actual, expected := resp, "some-response"; actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestCompletionAdapter_error(t *testing.T) {
+	t.Parallel()
+	var fake llmstesting.FakeChat[int]
+	fake.Err = errors.New("some-error")
+
+	llm := llms.NewCompletionAdapter[int](&fake)
+	if _, err := llm.Generate(context.Background(), "some-prompt", 1); err == nil {
+		t.Fatal("expected error")
+	}
+}
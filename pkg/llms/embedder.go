@@ -0,0 +1,69 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms
+
+import (
+	"context"
+	"math"
+)
+
+// TaskType is what embeddings are used for. Some models produce better
+// embeddings when they know it.
+type TaskType string
+
+const (
+	// TaskUnspecified lets the model pick its default.
+	TaskUnspecified TaskType = ""
+	// TaskRetrievalQuery is for a query used to search for documents.
+	TaskRetrievalQuery TaskType = "retrieval_query"
+	// TaskRetrievalDocument is for a document that is searched.
+	TaskRetrievalDocument TaskType = "retrieval_document"
+	// TaskSemanticSimilarity is for texts that are compared to each other.
+	TaskSemanticSimilarity TaskType = "semantic_similarity"
+	// TaskClassification is for texts that are classified.
+	TaskClassification TaskType = "classification"
+	// TaskClustering is for texts that are clustered.
+	TaskClustering TaskType = "clustering"
+)
+
+// Embedding is a vector that represents the meaning of a text.
+type Embedding []float32
+
+// Embedder embeds texts into vectors.
+type Embedder[TParams any] interface {
+	// Embed returns an embedding for each of the texts, in the same order.
+	Embed(ctx context.Context, texts []string, task TaskType, params TParams) ([]Embedding, error)
+}
+
+// CosineSimilarity returns the cosine of the angle between the embeddings. It
+// is 1 for embeddings that point in the same direction and 0 for unrelated
+// ones. It returns 0 if the embeddings have different dimensions or either is
+// zero.
+func CosineSimilarity(a, b Embedding) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
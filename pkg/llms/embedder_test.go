@@ -0,0 +1,72 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/google/go-react/pkg/llms"
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+)
+
+func TestCosineSimilarity(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		a, b     llms.Embedding
+		expected float64
+	}{
+		{name: "same", a: llms.Embedding{1, 2}, b: llms.Embedding{2, 4}, expected: 1},
+		{name: "opposite", a: llms.Embedding{1, 2}, b: llms.Embedding{-1, -2}, expected: -1},
+		{name: "orthogonal", a: llms.Embedding{1, 0}, b: llms.Embedding{0, 1}, expected: 0},
+		{name: "zero", a: llms.Embedding{0, 0}, b: llms.Embedding{0, 1}, expected: 0},
+		{name: "different dimensions", a: llms.Embedding{1}, b: llms.Embedding{1, 0}, expected: 0},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if actual, expected := llms.CosineSimilarity(tc.a, tc.b), tc.expected; math.Abs(actual-expected) > 1e-9 {
+				t.Fatalf("expected %v, got %v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestFakeEmbedder(t *testing.T) {
+	t.Parallel()
+
+	var fake llmstesting.FakeEmbedder[int]
+	embeddings, err := fake.Embed(context.Background(), []string{"some-text", "some-text", "other-text"}, llms.TaskUnspecified, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if actual, expected := len(embeddings[0]), 8; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+	if actual, expected := llms.CosineSimilarity(embeddings[0], embeddings[1]), 1.0; math.Abs(actual-expected) > 1e-6 {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+	if actual := llms.CosineSimilarity(embeddings[0], embeddings[2]); actual > 0.99 {
+		t.Fatalf("expected different texts to have different embeddings, got %v", actual)
+	}
+}
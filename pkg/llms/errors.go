@@ -0,0 +1,192 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrRateLimited is returned when the rate limit or quota is exceeded. The
+	// request can be retried, ideally after RetryAfter.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrTransient is returned when the request failed for a reason that is
+	// likely to go away (e.g., a server error or a network failure).
+	ErrTransient = errors.New("transient error")
+	// ErrInvalidRequest is returned when the request was rejected as invalid
+	// (e.g., an unknown model or a malformed parameter).
+	ErrInvalidRequest = errors.New("invalid request")
+	// ErrAuth is returned when the credentials are missing, invalid or lack
+	// permission.
+	ErrAuth = errors.New("authentication failed")
+	// ErrContextLength is returned when the prompt and requested tokens do not
+	// fit in the model's context window.
+	ErrContextLength = errors.New("context length exceeded")
+	// ErrSafetyBlocked is returned when the prompt or response was blocked by
+	// the safety filters.
+	ErrSafetyBlocked = errors.New("blocked by safety filters")
+)
+
+// APIError is returned when an API responds with an error. It wraps one of the
+// package's errors so that it can be checked with errors.Is.
+type APIError struct {
+	StatusCode int
+	// Status is the provider's status (e.g., RESOURCE_EXHAUSTED).
+	Status  string
+	Message string
+	// RetryAfter is how long the API asked to wait before retrying. It is zero
+	// when the API didn't say.
+	RetryAfter time.Duration
+	// Err is the package error that classifies the failure.
+	Err error
+}
+
+// Error implements error.
+func (e *APIError) Error() string {
+	if e.Status != "" {
+		return fmt.Sprintf("request failed with status code %d (%s): %s", e.StatusCode, e.Status, e.Message)
+	}
+	return fmt.Sprintf("request failed with status code %d: %s", e.StatusCode, e.Message)
+}
+
+// Unwrap returns the package error that classifies the failure.
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// ClassifyStatus returns the package error for an HTTP status code.
+func ClassifyStatus(statusCode int) error {
+	switch {
+	case statusCode == http.StatusUnauthorized, statusCode == http.StatusForbidden:
+		return ErrAuth
+	case statusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case statusCode == http.StatusRequestTimeout, statusCode >= http.StatusInternalServerError:
+		return ErrTransient
+	case statusCode >= http.StatusBadRequest:
+		return ErrInvalidRequest
+	default:
+		return nil
+	}
+}
+
+// ParseRetryAfter parses the value of a Retry-After header, which is either a
+// number of seconds or a date. It returns zero when the value is not valid.
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// IsRetryable returns whether the request that failed with err might succeed
+// if it is sent again. Errors that are not classified (e.g., from an LLM that
+// doesn't use the package's errors) are assumed to be retryable, while
+// canceled requests are not.
+func IsRetryable(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.Is(err, ErrRateLimited), errors.Is(err, ErrTransient):
+		return true
+	case errors.Is(err, ErrInvalidRequest),
+		errors.Is(err, ErrAuth),
+		errors.Is(err, ErrContextLength),
+		errors.Is(err, ErrSafetyBlocked),
+		errors.Is(err, ErrBudgetExceeded):
+		return false
+	default:
+		return true
+	}
+}
+
+// RetryAfter returns how long the API asked to wait before retrying, if it
+// did.
+func RetryAfter(err error) (time.Duration, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return apiErr.RetryAfter, true
+	}
+	return 0, false
+}
+
+// The classes returned by ErrorClass.
+const (
+	ClassRateLimited    = "rate_limited"
+	ClassTransient      = "transient"
+	ClassInvalidRequest = "invalid_request"
+	ClassAuth           = "auth"
+	ClassContextLength  = "context_length"
+	ClassSafetyBlocked  = "safety_blocked"
+	ClassBudgetExceeded = "budget_exceeded"
+	ClassCanceled       = "canceled"
+	ClassDeadline       = "deadline_exceeded"
+)
+
+// errorClasses are the errors that ErrorClass classifies, in the order they
+// are checked.
+var errorClasses = []struct {
+	class string
+	err   error
+}{
+	{ClassRateLimited, ErrRateLimited},
+	{ClassTransient, ErrTransient},
+	{ClassInvalidRequest, ErrInvalidRequest},
+	{ClassAuth, ErrAuth},
+	{ClassContextLength, ErrContextLength},
+	{ClassSafetyBlocked, ErrSafetyBlocked},
+	{ClassBudgetExceeded, ErrBudgetExceeded},
+	{ClassCanceled, context.Canceled},
+	{ClassDeadline, context.DeadlineExceeded},
+}
+
+// ErrorClass returns a short class for the error that wraps one of the
+// package's errors or a context error (e.g., to use as a metric label or to
+// record the error). It is empty when the error is nil or not one of them.
+func ErrorClass(err error) string {
+	if err == nil {
+		return ""
+	}
+	for _, c := range errorClasses {
+		if errors.Is(err, c.err) {
+			return c.class
+		}
+	}
+	return ""
+}
+
+// ClassError returns the error of the class returned by ErrorClass, or nil
+// for an unknown class.
+func ClassError(class string) error {
+	for _, c := range errorClasses {
+		if c.class == class {
+			return c.err
+		}
+	}
+	return nil
+}
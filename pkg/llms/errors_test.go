@@ -0,0 +1,182 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-react/pkg/llms"
+)
+
+func TestIsRetryable(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "nil", err: nil, expected: false},
+		{name: "rate limited", err: llms.ErrRateLimited, expected: true},
+		{name: "transient", err: fmt.Errorf("some-error: %w", llms.ErrTransient), expected: true},
+		{name: "invalid request", err: llms.ErrInvalidRequest, expected: false},
+		{name: "auth", err: llms.ErrAuth, expected: false},
+		{name: "context length", err: llms.ErrContextLength, expected: false},
+		{name: "safety blocked", err: llms.ErrSafetyBlocked, expected: false},
+		{name: "budget exceeded", err: fmt.Errorf("%w: spent 2 of 1", llms.ErrBudgetExceeded), expected: false},
+		{name: "api error", err: &llms.APIError{StatusCode: http.StatusServiceUnavailable, Err: llms.ErrTransient}, expected: true},
+		{name: "canceled", err: fmt.Errorf("%w: %w", llms.ErrTransient, context.Canceled), expected: false},
+		{name: "deadline exceeded", err: context.DeadlineExceeded, expected: false},
+		{name: "unclassified", err: errors.New("some-error"), expected: true},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if actual, expected := llms.IsRetryable(tc.err), tc.expected; actual != expected {
+				t.Fatalf("expected %v, got %v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestErrorClass(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{name: "nil", err: nil, expected: ""},
+		{name: "rate limited", err: fmt.Errorf("some-error: %w", llms.ErrRateLimited), expected: llms.ClassRateLimited},
+		{name: "api error", err: &llms.APIError{StatusCode: http.StatusBadRequest, Err: llms.ErrInvalidRequest}, expected: llms.ClassInvalidRequest},
+		{name: "budget exceeded", err: fmt.Errorf("%w: spent 2 of 1", llms.ErrBudgetExceeded), expected: llms.ClassBudgetExceeded},
+		{name: "deadline exceeded", err: context.DeadlineExceeded, expected: llms.ClassDeadline},
+		{name: "unclassified", err: errors.New("some-error"), expected: ""},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			class := llms.ErrorClass(tc.err)
+			if actual, expected := class, tc.expected; actual != expected {
+				t.Fatalf("expected %q, got %q", expected, actual)
+			}
+			if class == "" {
+				if err := llms.ClassError(class); err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			if actual, expected := errors.Is(tc.err, llms.ClassError(class)), true; actual != expected {
+				t.Fatalf("expected %v, got %v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestClassifyStatus(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		status   int
+		expected error
+	}{
+		{status: http.StatusOK, expected: nil},
+		{status: http.StatusBadRequest, expected: llms.ErrInvalidRequest},
+		{status: http.StatusNotFound, expected: llms.ErrInvalidRequest},
+		{status: http.StatusUnauthorized, expected: llms.ErrAuth},
+		{status: http.StatusForbidden, expected: llms.ErrAuth},
+		{status: http.StatusRequestTimeout, expected: llms.ErrTransient},
+		{status: http.StatusTooManyRequests, expected: llms.ErrRateLimited},
+		{status: http.StatusInternalServerError, expected: llms.ErrTransient},
+		{status: http.StatusServiceUnavailable, expected: llms.ErrTransient},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(http.StatusText(tc.status), func(t *testing.T) {
+			t.Parallel()
+
+			if actual, expected := llms.ClassifyStatus(tc.status), tc.expected; actual != expected {
+				t.Fatalf("expected %v, got %v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name     string
+		value    string
+		expected time.Duration
+	}{
+		{name: "empty", value: "", expected: 0},
+		{name: "seconds", value: "30", expected: 30 * time.Second},
+		{name: "negative seconds", value: "-1", expected: 0},
+		{name: "date", value: now.Add(time.Minute).Format(http.TimeFormat), expected: time.Minute},
+		{name: "date in the past", value: now.Add(-time.Minute).Format(http.TimeFormat), expected: 0},
+		{name: "invalid", value: "some-value", expected: 0},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if actual, expected := llms.ParseRetryAfter(tc.value, now), tc.expected; actual != expected {
+				t.Fatalf("expected %v, got %v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	err := fmt.Errorf("some-error: %w", &llms.APIError{
+		StatusCode: http.StatusTooManyRequests,
+		RetryAfter: 5 * time.Second,
+		Err:        llms.ErrRateLimited,
+	})
+	d, ok := llms.RetryAfter(err)
+	if !ok {
+		t.Fatal("expected a retry after")
+	}
+	if actual, expected := d, 5*time.Second; actual != expected {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+
+	if _, ok := llms.RetryAfter(llms.ErrRateLimited); ok {
+		t.Fatal("expected no retry after")
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrUnavailable is returned by an LLM created with NewFallback when none of
+// its backends could handle the request.
+var ErrUnavailable = errors.New("all LLM backends are unavailable")
+
+// FallbackOption is an option for NewFallback.
+type FallbackOption func(*fallbackConfig)
+
+type fallbackConfig struct {
+	threshold int
+	cooldown  time.Duration
+}
+
+// WithFailureThreshold sets how many consecutive failures open a backend's
+// circuit breaker. It defaults to 3 and must be at least 1.
+func WithFailureThreshold(n int) FallbackOption {
+	if n < 1 {
+		panic("n must be at least 1")
+	}
+	return func(c *fallbackConfig) {
+		c.threshold = n
+	}
+}
+
+// WithCooldown sets how long a backend is skipped once its circuit breaker
+// opens. After the cooldown, a single request is let through to check whether
+// it has recovered. It defaults to 30 seconds.
+func WithCooldown(d time.Duration) FallbackOption {
+	return func(c *fallbackConfig) {
+		c.cooldown = d
+	}
+}
+
+// NewFallback returns an LLM that sends each request to the first backend
+// that is available, falling back to the next one when it fails. Each backend
+// has a circuit breaker that skips it after consecutive failures. Errors that
+// IsRetryable reports as permanent, other than ErrAuth, are blamed on the
+// request rather than the backend, so they don't count as failures. An error
+// that wraps ErrBudgetExceeded is returned as is, since every backend would
+// fail the same way. Use MapParams to add backends that take different params.
+func NewFallback[TParams any](backends []LLM[TParams], opts ...FallbackOption) LLM[TParams] {
+	if len(backends) == 0 {
+		panic("no backends provided")
+	}
+
+	config := fallbackConfig{
+		threshold: 3,
+		cooldown:  30 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	f := fallback[TParams]{}
+	for _, b := range backends {
+		f.backends = append(f.backends, b)
+		f.breakers = append(f.breakers, &breaker{config: config})
+	}
+	return f
+}
+
+type fallback[TParams any] struct {
+	backends []LLM[TParams]
+	breakers []*breaker
+}
+
+// Generate implements LLM.
+func (f fallback[TParams]) Generate(ctx context.Context, prompt string, params TParams) (string, error) {
+	return fallbackCall(ctx, f, func(llm LLM[TParams]) (string, error) {
+		return llm.Generate(ctx, prompt, params)
+	})
+}
+
+// GenerateWithMetadata implements MetadataLLM.
+func (f fallback[TParams]) GenerateWithMetadata(ctx context.Context, prompt string, params TParams) (Result, error) {
+	return fallbackCall(ctx, f, func(llm LLM[TParams]) (Result, error) {
+		return GenerateWithMetadata(ctx, llm, prompt, params)
+	})
+}
+
+// GenerateStream implements StreamLLM. It only falls back when the stream
+// can't be started.
+func (f fallback[TParams]) GenerateStream(ctx context.Context, prompt string, params TParams) (Stream, error) {
+	return fallbackCall(ctx, f, func(llm LLM[TParams]) (Stream, error) {
+		return GenerateStream(ctx, llm, prompt, params)
+	})
+}
+
+// fallbackCall calls each available backend in order until one succeeds.
+func fallbackCall[TParams, T any](ctx context.Context, f fallback[TParams], call func(LLM[TParams]) (T, error)) (T, error) {
+	var (
+		empty T
+		errs  []error
+	)
+	for i, b := range f.backends {
+		breaker := f.breakers[i]
+		if !breaker.allow(time.Now()) {
+			continue
+		}
+
+		resp, err := call(b)
+		if err == nil {
+			breaker.success()
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			// The caller gave up, which says nothing about the backend.
+			breaker.cancel()
+			return empty, err
+		}
+		if errors.Is(err, ErrBudgetExceeded) {
+			// The budget is shared by the backends, which says nothing about
+			// this one either. The response of the request that exceeded it
+			// is returned along with the error.
+			breaker.cancel()
+			return resp, err
+		}
+		if IsRetryable(err) || errors.Is(err, ErrAuth) {
+			breaker.failure(time.Now())
+		} else {
+			// The backend is up but rejected the request (e.g., it was too
+			// long for its context window). Another backend might accept it.
+			breaker.success()
+		}
+		errs = append(errs, fmt.Errorf("backend %d: %w", i, err))
+	}
+	if len(errs) == 0 {
+		return empty, fmt.Errorf("%w: all circuit breakers are open", ErrUnavailable)
+	}
+	return empty, fmt.Errorf("%w: %w", ErrUnavailable, errors.Join(errs...))
+}
+
+// breaker is a circuit breaker. It is closed while the backend works, opens
+// after consecutive failures and half-opens after the cooldown to let a
+// single trial request through.
+type breaker struct {
+	config fallbackConfig
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+func (b *breaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.config.threshold {
+		return true
+	}
+	if b.trial || now.Sub(b.openedAt) < b.config.cooldown {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.trial = false
+}
+
+func (b *breaker) failure(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.failures >= b.config.threshold {
+		b.openedAt = now
+	}
+	b.trial = false
+}
+
+func (b *breaker) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
@@ -0,0 +1,232 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-react/pkg/llms"
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+)
+
+func TestFallback(t *testing.T) {
+	t.Parallel()
+
+	primary := &llmstesting.Fake[int]{Err: errors.New("some-error")}
+	secondary := &llmstesting.Fake[string]{AlwaysText: "some-response"}
+	llm := llms.NewFallback([]llms.LLM[int]{
+		primary,
+		llms.MapParams[int, string](secondary, strconv.Itoa),
+	},
+		llms.WithFailureThreshold(2),
+		llms.WithCooldown(50*time.Millisecond),
+	)
+
+	generate := func() {
+		t.Helper()
+		resp, err := llm.Generate(context.Background(), "some-prompt", 7)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual, expected := resp, "some-response"; actual != expected {
+			t.Fatalf("expected %q, got %q", expected, actual)
+		}
+	}
+
+	// The primary fails until its breaker opens, then it is skipped.
+	for i := 0; i < 4; i++ {
+		generate()
+	}
+	if actual, expected := len(primary.Prompts), 2; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+	if actual, expected := len(secondary.Prompts), 4; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+	if actual, expected := secondary.Params[0], "7"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+
+	// After the cooldown, a trial request is sent to the primary. It recovered,
+	// so the breaker closes.
+	time.Sleep(60 * time.Millisecond)
+	primary.Err = nil
+	primary.AlwaysText = "some-response"
+	for i := 0; i < 2; i++ {
+		generate()
+	}
+	if actual, expected := len(primary.Prompts), 4; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+	if actual, expected := len(secondary.Prompts), 4; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+}
+
+func TestFallback_halfOpenFailure(t *testing.T) {
+	t.Parallel()
+
+	primary := &llmstesting.Fake[int]{Err: errors.New("some-error")}
+	secondary := &llmstesting.Fake[int]{AlwaysText: "some-response"}
+	llm := llms.NewFallback([]llms.LLM[int]{primary, secondary},
+		llms.WithFailureThreshold(1),
+		llms.WithCooldown(50*time.Millisecond),
+	)
+
+	for _, wait := range []time.Duration{0, 0, 60 * time.Millisecond, 0} {
+		time.Sleep(wait)
+		if _, err := llm.Generate(context.Background(), "some-prompt", 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The first request opens the breaker, the trial after the cooldown fails
+	// and opens it again.
+	if actual, expected := len(primary.Prompts), 2; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+}
+
+func TestFallback_invalidRequest(t *testing.T) {
+	t.Parallel()
+
+	primary := &llmstesting.Fake[int]{Err: llms.ErrContextLength}
+	secondary := &llmstesting.Fake[int]{AlwaysText: "some-response"}
+	llm := llms.NewFallback([]llms.LLM[int]{primary, secondary}, llms.WithFailureThreshold(1))
+
+	for i := 0; i < 3; i++ {
+		if _, err := llm.Generate(context.Background(), "some-prompt", 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The primary rejected the request rather than failing, so its breaker
+	// stays closed.
+	if actual, expected := len(primary.Prompts), 3; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+}
+
+func TestFallback_unavailable(t *testing.T) {
+	t.Parallel()
+
+	someErr := errors.New("some-error")
+	llm := llms.NewFallback([]llms.LLM[int]{
+		&llmstesting.Fake[int]{Err: someErr},
+		&llmstesting.Fake[int]{Err: errors.New("other-error")},
+	}, llms.WithFailureThreshold(1))
+
+	_, err := llm.Generate(context.Background(), "some-prompt", 1)
+	if actual, expected := errors.Is(err, llms.ErrUnavailable), true; actual != expected {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+	if actual, expected := errors.Is(err, someErr), true; actual != expected {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+
+	// Both breakers are open now.
+	_, err = llm.Generate(context.Background(), "some-prompt", 1)
+	if actual, expected := errors.Is(err, llms.ErrUnavailable), true; actual != expected {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestFallback_budgetExceeded(t *testing.T) {
+	t.Parallel()
+
+	primary := &llmstesting.Fake[int]{Err: fmt.Errorf("%w: spent 2 of 1", llms.ErrBudgetExceeded)}
+	secondary := &llmstesting.Fake[int]{AlwaysText: "some-response"}
+	llm := llms.NewFallback([]llms.LLM[int]{primary, secondary}, llms.WithFailureThreshold(1))
+
+	for i := 0; i < 2; i++ {
+		_, err := llm.Generate(context.Background(), "some-prompt", 1)
+		if actual, expected := errors.Is(err, llms.ErrBudgetExceeded), true; actual != expected {
+			t.Fatalf("expected %v, got %v", expected, actual)
+		}
+		if actual, expected := errors.Is(err, llms.ErrUnavailable), false; actual != expected {
+			t.Fatalf("expected %v, got %v", expected, actual)
+		}
+	}
+
+	// The secondary was not tried and the primary's breaker didn't open.
+	if actual, expected := len(secondary.Prompts), 0; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+	if actual, expected := len(primary.Prompts), 2; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+}
+
+func TestWithFailureThreshold_invalid(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name string
+		n    int
+	}{
+		{name: "zero", n: 0},
+		{name: "negative", n: -1},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			defer func() {
+				if r := recover(); r == nil {
+					t.Error("expected panic")
+				}
+			}()
+
+			llms.WithFailureThreshold(tc.n)
+		})
+	}
+}
+
+func TestFallback_canceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	primary := &llmstesting.Fake[int]{
+		Errs:      map[string]error{"some-prompt": context.Canceled},
+		GenerateF: func(context.Context, string) { cancel() },
+	}
+	secondary := &llmstesting.Fake[int]{AlwaysText: "some-response"}
+	llm := llms.NewFallback([]llms.LLM[int]{primary, secondary}, llms.WithFailureThreshold(1))
+
+	if _, err := llm.Generate(ctx, "some-prompt", 1); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+	if actual, expected := len(secondary.Prompts), 0; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+
+	// The breaker didn't open, so the primary is still used.
+	primary.Errs = nil
+	primary.GenerateF = nil
+	if _, err := llm.Generate(context.Background(), "some-prompt", 1); err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := len(primary.Prompts), 2; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// ErrFunctionCallingUnsupported is returned when the LLM or model can't call
+// functions.
+var ErrFunctionCallingUnsupported = errors.New("function calling is not supported")
+
+// FunctionDeclaration describes a function that the LLM can call.
+type FunctionDeclaration struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Parameters is the JSON schema of the arguments. It describes an object
+	// with a property for each argument.
+	Parameters json.RawMessage `json:"parameters,omitempty"`
+}
+
+// FunctionCall is a call to a function that the LLM asked for.
+type FunctionCall struct {
+	Name string `json:"name"`
+	// Args is a JSON object with the arguments.
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+// FunctionCallingLLM is an LLM that can respond with structured calls to the
+// functions it is given.
+type FunctionCallingLLM[TParams any] interface {
+	LLM[TParams]
+	// GenerateWithFunctions generates a response from the given prompt and
+	// params. The response's FunctionCalls are set when the LLM decided to
+	// call any of the functions instead of (or as well as) responding with
+	// text.
+	GenerateWithFunctions(ctx context.Context, prompt string, functions []FunctionDeclaration, params TParams) (Result, error)
+}
+
+// GenerateWithFunctions calls GenerateWithFunctions if the LLM implements
+// FunctionCallingLLM. Otherwise, it returns ErrFunctionCallingUnsupported.
+func GenerateWithFunctions[TParams any](ctx context.Context, llm LLM[TParams], prompt string, functions []FunctionDeclaration, params TParams) (Result, error) {
+	if f, ok := llm.(FunctionCallingLLM[TParams]); ok {
+		return f.GenerateWithFunctions(ctx, prompt, functions, params)
+	}
+	return Result{}, ErrFunctionCallingUnsupported
+}
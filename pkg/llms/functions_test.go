@@ -0,0 +1,52 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-react/pkg/llms"
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+)
+
+func TestGenerateWithFunctions(t *testing.T) {
+	t.Parallel()
+
+	functions := []llms.FunctionDeclaration{{Name: "some-function"}}
+	fake := &llmstesting.Fake[int]{
+		FunctionCalls: map[string][]llms.FunctionCall{
+			"some-prompt": {{Name: "some-function"}},
+		},
+	}
+	result, err := llms.GenerateWithFunctions[int](context.Background(), fake, "some-prompt", functions, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := result.FunctionCalls[0].Name, "some-function"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+	if actual, expected := fake.Functions[0][0].Name, "some-function"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+
+	// LLMs that can't call functions return an error rather than silently
+	// ignoring them.
+	_, err = llms.GenerateWithFunctions[int](context.Background(), echo{}, "some-prompt", functions, 1)
+	if actual, expected := errors.Is(err, llms.ErrFunctionCallingUnsupported), true; actual != expected {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms
+
+// GenerationConfig holds the generation params that are common to the
+// backends. Prompts and agents can use it as their params, and each backend
+// has an adapter that converts it into its own params, so that the backend can
+// be picked by configuration:
+//
+//	llm := llms.MapParams(vertexLLM, vertex.FromGenerationConfig)
+//	chat := llms.MapChatParams(vertexChat, vertex.FromGenerationConfig)
+//
+// Params that a backend doesn't support are ignored by its adapter.
+type GenerationConfig struct {
+	Model       string
+	Temperature float64
+	TopK        int
+	TopP        float64
+	// MaxTokens is the max number of tokens to generate.
+	MaxTokens     int
+	StopSequences []string
+	Seed          int
+	// JSON constrains the response to valid JSON when the backend supports it.
+	JSON bool
+}
@@ -0,0 +1,246 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// latencyWindow is the number of recent latencies the percentile is
+	// computed from.
+	latencyWindow = 100
+	// minLatencySamples is the number of latencies needed before the hedge
+	// delay is computed from the percentile.
+	minLatencySamples = 10
+)
+
+// HedgeOption is an option for NewHedger.
+type HedgeOption func(*hedgeConfig)
+
+type hedgeConfig struct {
+	timeout    time.Duration
+	delay      time.Duration
+	percentile float64
+	logger     *slog.Logger
+}
+
+// WithAttemptTimeout limits how long each attempt can take. An attempt that
+// times out fails with ErrTransient, so that it can be retried.
+func WithAttemptTimeout(d time.Duration) HedgeOption {
+	return func(c *hedgeConfig) {
+		c.timeout = d
+	}
+}
+
+// WithHedgeDelay sends a hedged request when the first one has not responded
+// after d. When WithHedgePercentile is given as well, d is only used until
+// enough latencies have been observed.
+func WithHedgeDelay(d time.Duration) HedgeOption {
+	return func(c *hedgeConfig) {
+		c.delay = d
+	}
+}
+
+// WithHedgePercentile sends a hedged request when the first one has not
+// responded after the given percentile (e.g., 0.95) of the latencies of the
+// recent successful requests.
+func WithHedgePercentile(p float64) HedgeOption {
+	return func(c *hedgeConfig) {
+		c.percentile = p
+	}
+}
+
+// WithHedgeLogger logs when a hedged request is sent, which request won and
+// when an attempt times out.
+func WithHedgeLogger(logger *slog.Logger) HedgeOption {
+	return func(c *hedgeConfig) {
+		c.logger = logger
+	}
+}
+
+// NewHedger returns an LLM that limits how long each request can take and
+// sends a hedged duplicate of a request that is slower than usual (see
+// WithHedgeDelay and WithHedgePercentile). The first successful response is
+// returned and the other request is canceled. Streams are neither hedged nor
+// timed out.
+func NewHedger[TParams any](llm LLM[TParams], opts ...HedgeOption) LLM[TParams] {
+	var c hedgeConfig
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return hedger[TParams]{
+		llm:       llm,
+		config:    c,
+		latencies: &latencies{},
+	}
+}
+
+type hedger[TParams any] struct {
+	llm       LLM[TParams]
+	config    hedgeConfig
+	latencies *latencies
+}
+
+// Generate implements LLM.
+func (h hedger[TParams]) Generate(ctx context.Context, prompt string, params TParams) (string, error) {
+	result, err := h.GenerateWithMetadata(ctx, prompt, params)
+	if err != nil {
+		return "", err
+	}
+	return result.Text, nil
+}
+
+type hedgeAttempt struct {
+	n       int
+	result  Result
+	latency time.Duration
+	err     error
+}
+
+// GenerateWithMetadata implements MetadataLLM.
+func (h hedger[TParams]) GenerateWithMetadata(ctx context.Context, prompt string, params TParams) (Result, error) {
+	// Canceling the context cancels the request that lost.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The channel is buffered so that the request that lost doesn't block.
+	attempts := make(chan hedgeAttempt, 2)
+	send := func(n int) {
+		go func() {
+			start := time.Now()
+			result, err := h.attempt(ctx, n, prompt, params)
+			attempts <- hedgeAttempt{n: n, result: result, latency: time.Since(start), err: err}
+		}()
+	}
+	send(1)
+	pending := 1
+
+	var hedge <-chan time.Time
+	if delay, ok := h.hedgeDelay(); ok {
+		t := time.NewTimer(delay)
+		defer t.Stop()
+		hedge = t.C
+	}
+
+	var firstErr error
+	for {
+		select {
+		case <-hedge:
+			hedge = nil
+			h.log(ctx, slog.LevelInfo, "llm hedge sent")
+			send(2)
+			pending++
+		case a := <-attempts:
+			pending--
+			if a.err == nil {
+				h.latencies.add(a.latency)
+				if a.n > 1 || pending > 0 {
+					h.log(ctx, slog.LevelInfo, "llm hedge won", slog.Int("attempt", a.n), slog.Duration("latency", a.latency))
+				}
+				return a.result, nil
+			}
+			if firstErr == nil {
+				firstErr = a.err
+			}
+			if pending == 0 {
+				return Result{}, firstErr
+			}
+		}
+	}
+}
+
+// GenerateStream implements StreamLLM. The stream is not hedged.
+func (h hedger[TParams]) GenerateStream(ctx context.Context, prompt string, params TParams) (Stream, error) {
+	return GenerateStream(ctx, h.llm, prompt, params)
+}
+
+// attempt sends a request, limited by the attempt timeout.
+func (h hedger[TParams]) attempt(ctx context.Context, n int, prompt string, params TParams) (Result, error) {
+	if h.config.timeout <= 0 {
+		return GenerateWithMetadata(ctx, h.llm, prompt, params)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, h.config.timeout)
+	defer cancel()
+	result, err := GenerateWithMetadata(attemptCtx, h.llm, prompt, params)
+	if err != nil && ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+		h.log(ctx, slog.LevelWarn, "llm attempt timed out", slog.Int("attempt", n), slog.Duration("timeout", h.config.timeout))
+		// The context's error is not wrapped, as it would make the error look
+		// like the caller's deadline was exceeded and it wouldn't be retried.
+		return Result{}, fmt.Errorf("%w: attempt timed out after %v", ErrTransient, h.config.timeout)
+	}
+	return result, err
+}
+
+// hedgeDelay returns how long to wait before sending a hedged request, and
+// false if no hedged request should be sent.
+func (h hedger[TParams]) hedgeDelay() (time.Duration, bool) {
+	if h.config.percentile > 0 {
+		if d, ok := h.latencies.percentile(h.config.percentile); ok {
+			return d, true
+		}
+	}
+	return h.config.delay, h.config.delay > 0
+}
+
+func (h hedger[TParams]) log(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr) {
+	if h.config.logger == nil {
+		return
+	}
+	h.config.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// latencies is a window of the recent latencies.
+type latencies struct {
+	mu     sync.Mutex
+	window []time.Duration
+	next   int
+}
+
+func (l *latencies) add(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.window) < latencyWindow {
+		l.window = append(l.window, d)
+		return
+	}
+	l.window[l.next] = d
+	l.next = (l.next + 1) % latencyWindow
+}
+
+// percentile returns the pth percentile (e.g., 0.95) of the window, and false
+// if there are not enough latencies yet.
+func (l *latencies) percentile(p float64) (time.Duration, bool) {
+	l.mu.Lock()
+	sorted := append([]time.Duration(nil), l.window...)
+	l.mu.Unlock()
+	if len(sorted) < minLatencySamples {
+		return 0, false
+	}
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	i := int(p * float64(len(sorted)-1))
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i], true
+}
@@ -0,0 +1,201 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-react/pkg/llms"
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+)
+
+// slowLLM responds after the delay for each call, in order. The last delay is
+// used for the calls after that.
+type slowLLM struct {
+	mu       sync.Mutex
+	delays   []time.Duration
+	calls    int
+	canceled int
+}
+
+func (l *slowLLM) Generate(ctx context.Context, prompt string, params int) (string, error) {
+	l.mu.Lock()
+	l.calls++
+	n := l.calls
+	delay := l.delays[len(l.delays)-1]
+	if n <= len(l.delays) {
+		delay = l.delays[n-1]
+	}
+	l.mu.Unlock()
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return fmt.Sprintf("response %d", n), nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.canceled++
+		l.mu.Unlock()
+		return "", ctx.Err()
+	}
+}
+
+func (l *slowLLM) stats() (calls, canceled int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.calls, l.canceled
+}
+
+func TestHedger(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name             string
+		delays           []time.Duration
+		opts             []llms.HedgeOption
+		expected         string
+		expectedErr      error
+		expectedCalls    int
+		expectedCanceled int
+	}{
+		{
+			name:          "fast response is not hedged",
+			delays:        []time.Duration{0},
+			opts:          []llms.HedgeOption{llms.WithHedgeDelay(time.Minute)},
+			expected:      "response 1",
+			expectedCalls: 1,
+		},
+		{
+			name:             "hedged request wins",
+			delays:           []time.Duration{time.Minute, 0},
+			opts:             []llms.HedgeOption{llms.WithHedgeDelay(10 * time.Millisecond)},
+			expected:         "response 2",
+			expectedCalls:    2,
+			expectedCanceled: 1,
+		},
+		{
+			name:             "attempt times out",
+			delays:           []time.Duration{time.Minute},
+			opts:             []llms.HedgeOption{llms.WithAttemptTimeout(10 * time.Millisecond)},
+			expectedErr:      llms.ErrTransient,
+			expectedCalls:    1,
+			expectedCanceled: 1,
+		},
+		{
+			name:   "both attempts time out",
+			delays: []time.Duration{time.Minute},
+			opts: []llms.HedgeOption{
+				llms.WithAttemptTimeout(20 * time.Millisecond),
+				llms.WithHedgeDelay(10 * time.Millisecond),
+			},
+			expectedErr:      llms.ErrTransient,
+			expectedCalls:    2,
+			expectedCanceled: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			slow := &slowLLM{delays: tc.delays}
+			llm := llms.NewHedger[int](slow, tc.opts...)
+
+			resp, err := llm.Generate(context.Background(), "some-prompt", 1)
+			if actual, expected := err, tc.expectedErr; !errors.Is(actual, expected) {
+				t.Fatalf("expected %v, got %v", expected, actual)
+			}
+			if err != nil && !llms.IsRetryable(err) {
+				t.Fatalf("expected %v to be retryable", err)
+			}
+			if actual, expected := resp, tc.expected; actual != expected {
+				t.Fatalf("expected %q, got %q", expected, actual)
+			}
+
+			calls, canceled := slow.stats()
+			if actual, expected := calls, tc.expectedCalls; actual != expected {
+				t.Fatalf("expected %d calls, got %d", expected, actual)
+			}
+			// The request that lost is canceled when Generate returns but it
+			// may not have noticed yet, so wait for it.
+			for i := 0; i < 100 && canceled < tc.expectedCanceled; i++ {
+				time.Sleep(time.Millisecond)
+				_, canceled = slow.stats()
+			}
+			if actual, expected := canceled, tc.expectedCanceled; actual != expected {
+				t.Fatalf("expected %d canceled, got %d", expected, actual)
+			}
+		})
+	}
+}
+
+func TestHedger_percentile(t *testing.T) {
+	t.Parallel()
+
+	// The first 10 requests are fast and, once the percentile is known, the
+	// next one is slow and hedged.
+	delays := make([]time.Duration, 11)
+	delays[10] = time.Minute
+	delays = append(delays, 0)
+	slow := &slowLLM{delays: delays}
+
+	var buf bytes.Buffer
+	llm := llms.NewHedger[int](slow,
+		llms.WithHedgePercentile(0.9),
+		llms.WithHedgeLogger(slog.New(slog.NewTextHandler(&buf, nil))),
+	)
+
+	for i := 0; i < 11; i++ {
+		if _, err := llm.Generate(context.Background(), "some-prompt", 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	calls, _ := slow.stats()
+	if actual, expected := calls, 12; actual != expected {
+		t.Fatalf("expected %d calls, got %d", expected, actual)
+	}
+	for _, expected := range []string{`msg="llm hedge sent"`, `msg="llm hedge won" attempt=2`} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Fatalf("expected %q in the logs, got %q", expected, buf.String())
+		}
+	}
+}
+
+func TestHedger_error(t *testing.T) {
+	t.Parallel()
+
+	fake := &llmstesting.Fake[int]{Err: llms.ErrInvalidRequest}
+	llm := llms.NewHedger[int](fake, llms.WithHedgeDelay(time.Minute))
+
+	// The error is returned without waiting to send a hedged request.
+	if _, err := llm.Generate(context.Background(), "some-prompt", 1); !errors.Is(err, llms.ErrInvalidRequest) {
+		t.Fatalf("expected %v, got %v", llms.ErrInvalidRequest, err)
+	}
+	if actual, expected := len(fake.Prompts), 1; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+}
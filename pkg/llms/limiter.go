@@ -0,0 +1,189 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter limits the number of requests and tokens sent per minute. A single
+// Limiter can be shared by several LLMs (e.g., all the LLMs that use the same
+// quota) and is safe for concurrent use.
+type Limiter struct {
+	mu       sync.Mutex
+	requests *bucket
+	tokens   *bucket
+}
+
+// NewLimiter returns a Limiter that allows the given number of requests and
+// tokens per minute. A limit of zero means there is no limit. The full limit
+// is available at once, so short bursts are allowed.
+func NewLimiter(requestsPerMinute, tokensPerMinute int) *Limiter {
+	now := time.Now()
+	return &Limiter{
+		requests: newBucket(requestsPerMinute, now),
+		tokens:   newBucket(tokensPerMinute, now),
+	}
+}
+
+// Wait blocks until a request with the given number of tokens is allowed or
+// the context is done.
+func (l *Limiter) Wait(ctx context.Context, tokens int) error {
+	l.mu.Lock()
+	now := time.Now()
+	delay := l.requests.reserve(1, now)
+	if d := l.tokens.reserve(tokens, now); d > delay {
+		delay = d
+	}
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		// Give the reservation back so that other requests don't wait for a
+		// request that never happened.
+		l.mu.Lock()
+		l.requests.refund(1)
+		l.tokens.refund(tokens)
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// adjust corrects the number of tokens that were reserved once the actual
+// usage is known.
+func (l *Limiter) adjust(reserved, actual int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens.refund(reserved - actual)
+}
+
+// bucket is a token bucket that is refilled continuously.
+type bucket struct {
+	capacity float64
+	// rate is how many are added per second.
+	rate  float64
+	level float64
+	last  time.Time
+}
+
+func newBucket(perMinute int, now time.Time) *bucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &bucket{
+		capacity: float64(perMinute),
+		rate:     float64(perMinute) / 60,
+		level:    float64(perMinute),
+		last:     now,
+	}
+}
+
+// reserve takes n from the bucket and returns how long to wait until they are
+// available. The level goes negative while there are outstanding
+// reservations.
+func (b *bucket) reserve(n int, now time.Time) time.Duration {
+	if b == nil {
+		return 0
+	}
+
+	b.level += now.Sub(b.last).Seconds() * b.rate
+	if b.level > b.capacity {
+		b.level = b.capacity
+	}
+	b.last = now
+
+	// A request larger than the bucket could never be allowed, so it takes the
+	// whole bucket instead.
+	need := float64(n)
+	if need > b.capacity {
+		need = b.capacity
+	}
+	b.level -= need
+	if b.level >= 0 {
+		return 0
+	}
+	return time.Duration(-b.level / b.rate * float64(time.Second))
+}
+
+// refund gives n back to the bucket. A negative n takes more from it.
+func (b *bucket) refund(n int) {
+	if b == nil {
+		return
+	}
+	b.level += math.Min(float64(n), b.capacity)
+	if b.level > b.capacity {
+		b.level = b.capacity
+	}
+}
+
+// NewRateLimited returns an LLM that waits for the Limiter before each request
+// to the given LLM. The tokens are estimated from the prompt with
+// EstimateTokens and corrected with the actual usage when the LLM reports it.
+func NewRateLimited[TParams any](llm LLM[TParams], limiter *Limiter) LLM[TParams] {
+	return rateLimited[TParams]{
+		llm:     llm,
+		limiter: limiter,
+	}
+}
+
+type rateLimited[TParams any] struct {
+	llm     LLM[TParams]
+	limiter *Limiter
+}
+
+// Generate implements LLM.
+func (r rateLimited[TParams]) Generate(ctx context.Context, prompt string, params TParams) (string, error) {
+	result, err := r.GenerateWithMetadata(ctx, prompt, params)
+	if err != nil {
+		return "", err
+	}
+	return result.Text, nil
+}
+
+// GenerateWithMetadata implements MetadataLLM.
+func (r rateLimited[TParams]) GenerateWithMetadata(ctx context.Context, prompt string, params TParams) (Result, error) {
+	tokens := EstimateTokens(prompt)
+	if err := r.limiter.Wait(ctx, tokens); err != nil {
+		return Result{}, err
+	}
+
+	result, err := GenerateWithMetadata(ctx, r.llm, prompt, params)
+	if err != nil {
+		return Result{}, err
+	}
+	if result.Usage != (Usage{}) {
+		r.limiter.adjust(tokens, result.Usage.InputTokens+result.Usage.OutputTokens)
+	}
+	return result, nil
+}
+
+// GenerateStream implements StreamLLM.
+func (r rateLimited[TParams]) GenerateStream(ctx context.Context, prompt string, params TParams) (Stream, error) {
+	if err := r.limiter.Wait(ctx, EstimateTokens(prompt)); err != nil {
+		return nil, err
+	}
+	return GenerateStream(ctx, r.llm, prompt, params)
+}
@@ -0,0 +1,153 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-react/pkg/llms"
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+)
+
+func TestRateLimited(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		limiter *llms.Limiter
+		prompts []string
+		allowed int
+	}{
+		{
+			name:    "requests",
+			limiter: llms.NewLimiter(2, 0),
+			prompts: []string{"a", "b", "c"},
+			allowed: 2,
+		},
+		{
+			name:    "tokens",
+			limiter: llms.NewLimiter(0, 10),
+			prompts: []string{strings.Repeat("a", 24), strings.Repeat("b", 16), "c"},
+			allowed: 2,
+		},
+		{
+			name:    "prompt larger than the limit",
+			limiter: llms.NewLimiter(0, 10),
+			prompts: []string{strings.Repeat("a", 100), "b"},
+			allowed: 1,
+		},
+		{
+			name:    "unlimited",
+			limiter: llms.NewLimiter(0, 0),
+			prompts: []string{"a", "b", "c"},
+			allowed: 3,
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := &llmstesting.Fake[int]{AlwaysText: "some-response"}
+			llm := llms.NewRateLimited[int](fake, tc.limiter)
+
+			for i, prompt := range tc.prompts {
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				_, err := llm.Generate(ctx, prompt, 1)
+				cancel()
+
+				if i < tc.allowed {
+					if err != nil {
+						t.Fatalf("%d: %v", i, err)
+					}
+					continue
+				}
+				if actual, expected := errors.Is(err, context.DeadlineExceeded), true; actual != expected {
+					t.Fatalf("%d: expected %v, got %v: %v", i, expected, actual, err)
+				}
+			}
+			if actual, expected := len(fake.Prompts), tc.allowed; actual != expected {
+				t.Fatalf("expected %d, got %d", expected, actual)
+			}
+		})
+	}
+}
+
+func TestRateLimited_usage(t *testing.T) {
+	t.Parallel()
+
+	// The prompt is estimated to be a single token, but the LLM reports that it
+	// used the whole limit.
+	fake := &llmstesting.Fake[int]{
+		Results: map[string]llms.Result{
+			"a": {Text: "some-response", Usage: llms.Usage{InputTokens: 4, OutputTokens: 6}},
+		},
+	}
+	llm := llms.NewRateLimited[int](fake, llms.NewLimiter(0, 10))
+
+	if _, err := llm.Generate(context.Background(), "a", 1); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := llm.Generate(ctx, "a", 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestRateLimited_shared(t *testing.T) {
+	t.Parallel()
+
+	// 600 requests per minute is one every 100ms once the burst is used up.
+	limiter := llms.NewLimiter(600, 0)
+	for i := 0; i < 600; i++ {
+		if err := limiter.Wait(context.Background(), 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	llm := llms.NewRateLimited[int](echo{}, limiter)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := llm.Generate(context.Background(), "some-prompt", 1); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if actual, expected := time.Since(start), 250*time.Millisecond; actual < expected {
+		t.Fatalf("expected to wait at least %v, waited %v", expected, actual)
+	}
+}
+
+// echo is an LLM that is safe for concurrent use.
+type echo struct{}
+
+func (echo) Generate(ctx context.Context, prompt string, params int) (string, error) {
+	return prompt, nil
+}
@@ -3,6 +3,7 @@ package llms
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 )
@@ -22,30 +23,164 @@ type logger[TParams any] struct {
 }
 
 type loggerData[TParams any] struct {
-	Prompt   string  `json:"prompt"`
-	Response string  `json:"response"`
-	Params   TParams `json:"params"`
-	Err      string  `json:"err"`
+	Prompt       string       `json:"prompt"`
+	Response     string       `json:"response"`
+	Params       TParams      `json:"params"`
+	Err          string       `json:"err"`
+	Usage        *Usage       `json:"usage,omitempty"`
+	FinishReason FinishReason `json:"finishReason,omitempty"`
 }
 
 // Generate implements the LLM interface.
-func (l logger[TParams]) Generate(ctx context.Context, prompt string, params TParams) (out string, err error) {
+func (l logger[TParams]) Generate(ctx context.Context, prompt string, params TParams) (string, error) {
+	result, err := l.GenerateWithMetadata(ctx, prompt, params)
+	if err != nil {
+		return "", err
+	}
+	return result.Text, nil
+}
+
+// GenerateWithMetadata implements the MetadataLLM interface. The token usage
+// and finish reason are written along with the response when the LLM reports
+// them.
+func (l logger[TParams]) GenerateWithMetadata(ctx context.Context, prompt string, params TParams) (out Result, err error) {
 	data := loggerData[TParams]{
 		Prompt: prompt,
 		Params: params,
 	}
 	defer func() {
-		if e := json.NewEncoder(l.out).Encode(data); err != nil {
+		if e := json.NewEncoder(l.out).Encode(data); e != nil && err == nil {
 			err = fmt.Errorf("logger failed to encode and write to writer: %w", e)
-			return
 		}
 	}()
 
-	resp, err := l.llm.Generate(ctx, prompt, params)
+	result, err := GenerateWithMetadata(ctx, l.llm, prompt, params)
 	if err != nil {
 		data.Err = err.Error()
+		return Result{}, err
+	}
+	data.Response = result.Text
+	if result.Usage != (Usage{}) {
+		data.Usage = &result.Usage
+	}
+	data.FinishReason = result.FinishReason
+	return result, nil
+}
+
+// GenerateStream implements the StreamLLM interface. The assembled response is
+// written once the stream has been fully read or closed.
+func (l logger[TParams]) GenerateStream(ctx context.Context, prompt string, params TParams) (Stream, error) {
+	data := loggerData[TParams]{
+		Prompt: prompt,
+		Params: params,
+	}
+	s, err := GenerateStream(ctx, l.llm, prompt, params)
+	if err != nil {
+		data.Err = err.Error()
+		if e := json.NewEncoder(l.out).Encode(data); e != nil {
+			return nil, fmt.Errorf("logger failed to encode and write to writer: %w", e)
+		}
+		return nil, err
+	}
+	return &loggerStream[TParams]{
+		s:    s,
+		out:  l.out,
+		data: data,
+	}, nil
+}
+
+type loggerStream[TParams any] struct {
+	s       Stream
+	out     io.Writer
+	data    loggerData[TParams]
+	written bool
+}
+
+// Recv implements Stream.
+func (s *loggerStream[TParams]) Recv() (string, error) {
+	chunk, err := s.s.Recv()
+	if errors.Is(err, io.EOF) {
+		if e := s.write(); e != nil {
+			return "", e
+		}
+		return "", err
+	}
+	if err != nil {
+		s.data.Err = err.Error()
+		if e := s.write(); e != nil {
+			return "", e
+		}
 		return "", err
 	}
-	data.Response = resp
-	return resp, nil
+	s.data.Response += chunk
+	return chunk, nil
+}
+
+// Close implements Stream.
+func (s *loggerStream[TParams]) Close() error {
+	err := s.s.Close()
+	if e := s.write(); e != nil {
+		return e
+	}
+	return err
+}
+
+func (s *loggerStream[TParams]) write() error {
+	if s.written {
+		return nil
+	}
+	s.written = true
+	if err := json.NewEncoder(s.out).Encode(s.data); err != nil {
+		return fmt.Errorf("logger failed to encode and write to writer: %w", err)
+	}
+	return nil
+}
+
+// NewEmbedderLogger creates a logger that wraps the Embedder. It will write the
+// texts and the number and dimensions of the embeddings to the given
+// io.Writer. The embeddings themselves are not written.
+func NewEmbedderLogger[TParams any](embedder Embedder[TParams], out io.Writer) Embedder[TParams] {
+	return embedderLogger[TParams]{
+		embedder: embedder,
+		out:      out,
+	}
+}
+
+type embedderLogger[TParams any] struct {
+	embedder Embedder[TParams]
+	out      io.Writer
+}
+
+type embedderLoggerData[TParams any] struct {
+	Texts      []string `json:"texts"`
+	Task       TaskType `json:"task,omitempty"`
+	Params     TParams  `json:"params"`
+	Embeddings int      `json:"embeddings"`
+	Dimensions int      `json:"dimensions,omitempty"`
+	Err        string   `json:"err"`
+}
+
+// Embed implements the Embedder interface.
+func (l embedderLogger[TParams]) Embed(ctx context.Context, texts []string, task TaskType, params TParams) (out []Embedding, err error) {
+	data := embedderLoggerData[TParams]{
+		Texts:  texts,
+		Task:   task,
+		Params: params,
+	}
+	defer func() {
+		if e := json.NewEncoder(l.out).Encode(data); e != nil && err == nil {
+			err = fmt.Errorf("logger failed to encode and write to writer: %w", e)
+		}
+	}()
+
+	embeddings, err := l.embedder.Embed(ctx, texts, task, params)
+	if err != nil {
+		data.Err = err.Error()
+		return nil, err
+	}
+	data.Embeddings = len(embeddings)
+	if len(embeddings) > 0 {
+		data.Dimensions = len(embeddings[0])
+	}
+	return embeddings, nil
 }
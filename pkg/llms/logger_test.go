@@ -79,3 +79,165 @@ func TestLogger_error(t *testing.T) {
 		t.Errorf("expected %q, got %q", expected, actual)
 	}
 }
+
+func TestLogger_stream(t *testing.T) {
+	t.Parallel()
+	var fake llmstesting.Fake[int]
+	var buf bytes.Buffer
+
+	fake.Chunks = map[string][]string{
+		"some-prompt": {"some-", "response"},
+	}
+
+	logger := llms.NewLogger[int](&fake, &buf)
+
+	s, err := llms.GenerateStream(context.Background(), logger, "some-prompt", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := llms.ReadStream(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, actual := "some-response", resp; expected != actual {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+
+	var m map[string]any
+	dec := json.NewDecoder(&buf)
+	if err := dec.Decode(&m); err != nil {
+		t.Fatal(err)
+	}
+	if expected, actual := "some-prompt", m["prompt"]; expected != actual {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+	if expected, actual := "some-response", m["response"]; expected != actual {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+	if expected, actual := false, dec.More(); expected != actual {
+		t.Errorf("expected the stream to be logged once")
+	}
+}
+
+func TestLogger_metadata(t *testing.T) {
+	t.Parallel()
+	var fake llmstesting.Fake[int]
+	var buf bytes.Buffer
+
+	fake.Results = map[string]llms.Result{
+		"some-prompt": {
+			Text:         "some-response",
+			Usage:        llms.Usage{InputTokens: 3, OutputTokens: 5},
+			FinishReason: llms.FinishReasonMaxTokens,
+		},
+	}
+
+	logger := llms.NewLogger[int](&fake, &buf)
+
+	result, err := llms.GenerateWithMetadata(context.Background(), logger, "some-prompt", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, actual := llms.FinishReasonMaxTokens, result.FinishReason; expected != actual {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+
+	var m map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if expected, actual := "some-response", m["response"]; expected != actual {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+	if expected, actual := "max_tokens", m["finishReason"]; expected != actual {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+	usage := m["usage"].(map[string]any)
+	if expected, actual := 3.0, usage["inputTokens"]; expected != actual {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+	if expected, actual := 5.0, usage["outputTokens"]; expected != actual {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestEmbedderLogger(t *testing.T) {
+	t.Parallel()
+	fake := llmstesting.FakeEmbedder[int]{Dimensions: 4}
+	var buf bytes.Buffer
+
+	logger := llms.NewEmbedderLogger[int](&fake, &buf)
+
+	embeddings, err := logger.Embed(context.Background(), []string{"some-text", "other-text"}, llms.TaskRetrievalDocument, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, actual := 2, len(embeddings); expected != actual {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+
+	var m map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if expected, actual := "other-text", m["texts"].([]any)[1]; expected != actual {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+	if expected, actual := "retrieval_document", m["task"]; expected != actual {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+	if expected, actual := 2.0, m["embeddings"]; expected != actual {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+	if expected, actual := 4.0, m["dimensions"]; expected != actual {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestEmbedderLogger_error(t *testing.T) {
+	t.Parallel()
+	fake := llmstesting.FakeEmbedder[int]{Err: errors.New("some-error")}
+	var buf bytes.Buffer
+
+	logger := llms.NewEmbedderLogger[int](&fake, &buf)
+
+	if _, err := logger.Embed(context.Background(), []string{"some-text"}, llms.TaskUnspecified, 1); err == nil {
+		t.Fatal("expected error")
+	}
+
+	var m map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if expected, actual := "some-error", m["err"]; expected != actual {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestLogger_writeError(t *testing.T) {
+	t.Parallel()
+
+	// The LLM's error is returned rather than the logger's.
+	someErr := errors.New("some-error")
+	logger := llms.NewLogger[int](&llmstesting.Fake[int]{Err: someErr}, failingWriter{})
+	_, err := logger.Generate(context.Background(), "some-prompt", 1)
+	if actual, expected := errors.Is(err, someErr), true; actual != expected {
+		t.Fatalf("expected %v, got %v: %v", expected, actual, err)
+	}
+
+	// The logger's error is returned when the LLM succeeds.
+	logger = llms.NewLogger[int](&llmstesting.Fake[int]{AlwaysText: "some-response"}, failingWriter{})
+	_, err = logger.Generate(context.Background(), "some-prompt", 1)
+	if actual, expected := errors.Is(err, errWrite), true; actual != expected {
+		t.Fatalf("expected %v, got %v: %v", expected, actual, err)
+	}
+}
+
+var errWrite = errors.New("some-write-error")
+
+// failingWriter is an io.Writer that always fails.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errWrite
+}
@@ -0,0 +1,91 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms
+
+import "context"
+
+// MapParams returns an LLM that converts the params with f before calling the
+// given LLM. It is used to combine backends with different params (e.g., with
+// NewFallback).
+func MapParams[TFrom, TTo any](llm LLM[TTo], f func(TFrom) TTo) LLM[TFrom] {
+	return mappedParams[TFrom, TTo]{
+		llm: llm,
+		f:   f,
+	}
+}
+
+type mappedParams[TFrom, TTo any] struct {
+	llm LLM[TTo]
+	f   func(TFrom) TTo
+}
+
+// Generate implements LLM.
+func (m mappedParams[TFrom, TTo]) Generate(ctx context.Context, prompt string, params TFrom) (string, error) {
+	return m.llm.Generate(ctx, prompt, m.f(params))
+}
+
+// GenerateWithMetadata implements MetadataLLM.
+func (m mappedParams[TFrom, TTo]) GenerateWithMetadata(ctx context.Context, prompt string, params TFrom) (Result, error) {
+	return GenerateWithMetadata(ctx, m.llm, prompt, m.f(params))
+}
+
+// GenerateStream implements StreamLLM.
+func (m mappedParams[TFrom, TTo]) GenerateStream(ctx context.Context, prompt string, params TFrom) (Stream, error) {
+	return GenerateStream(ctx, m.llm, prompt, m.f(params))
+}
+
+// GenerateCandidates implements CandidatesLLM.
+func (m mappedParams[TFrom, TTo]) GenerateCandidates(ctx context.Context, prompt string, params TFrom, n int) ([]Result, error) {
+	return GenerateCandidates(ctx, m.llm, prompt, m.f(params), n)
+}
+
+// CountTokens implements TokenCounter.
+func (m mappedParams[TFrom, TTo]) CountTokens(ctx context.Context, prompt string, params TFrom) (int, error) {
+	return CountTokens(ctx, m.llm, prompt, m.f(params))
+}
+
+// ModelInfo implements ModelInfoLLM.
+func (m mappedParams[TFrom, TTo]) ModelInfo(params TFrom) (ModelInfo, bool) {
+	return LookupModelInfo(m.llm, m.f(params))
+}
+
+// GenerateWithFunctions implements FunctionCallingLLM.
+func (m mappedParams[TFrom, TTo]) GenerateWithFunctions(ctx context.Context, prompt string, functions []FunctionDeclaration, params TFrom) (Result, error) {
+	return GenerateWithFunctions(ctx, m.llm, prompt, functions, m.f(params))
+}
+
+// GenerateMultimodal implements MultimodalLLM.
+func (m mappedParams[TFrom, TTo]) GenerateMultimodal(ctx context.Context, parts []Part, params TFrom) (Result, error) {
+	return GenerateMultimodal(ctx, m.llm, parts, m.f(params))
+}
+
+// MapChatParams returns a ChatLLM that converts the params with f before
+// calling the given ChatLLM, like MapParams does for an LLM.
+func MapChatParams[TFrom, TTo any](chat ChatLLM[TTo], f func(TFrom) TTo) ChatLLM[TFrom] {
+	return mappedChatParams[TFrom, TTo]{
+		chat: chat,
+		f:    f,
+	}
+}
+
+type mappedChatParams[TFrom, TTo any] struct {
+	chat ChatLLM[TTo]
+	f    func(TFrom) TTo
+}
+
+// Chat implements ChatLLM.
+func (m mappedChatParams[TFrom, TTo]) Chat(ctx context.Context, messages []Message, params TFrom) (Message, error) {
+	return m.chat.Chat(ctx, messages, m.f(params))
+}
@@ -0,0 +1,95 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// ErrMultimodalUnsupported is returned when the LLM or model can't take parts
+// other than text.
+var ErrMultimodalUnsupported = errors.New("multimodal prompts are not supported")
+
+// Part is a part of a multimodal prompt. Only one of Text, Data or FileURI is
+// set.
+type Part struct {
+	Text string `json:"text,omitempty"`
+	// MIMEType is the type of the Data or of the file at the FileURI (e.g.,
+	// image/png).
+	MIMEType string `json:"mimeType,omitempty"`
+	// Data is the content of the part (e.g., the bytes of an image).
+	Data []byte `json:"data,omitempty"`
+	// FileURI is the location of the file (e.g., a gs:// URI).
+	FileURI string `json:"fileURI,omitempty"`
+}
+
+// TextPart returns a Part with the given text.
+func TextPart(text string) Part {
+	return Part{Text: text}
+}
+
+// DataPart returns a Part with the given data inline.
+func DataPart(mimeType string, data []byte) Part {
+	return Part{MIMEType: mimeType, Data: data}
+}
+
+// FilePart returns a Part that refers to the file at the given URI.
+func FilePart(mimeType, uri string) Part {
+	return Part{MIMEType: mimeType, FileURI: uri}
+}
+
+// IsText returns whether the part only holds text.
+func (p Part) IsText() bool {
+	return p.Data == nil && p.FileURI == ""
+}
+
+// MultimodalLLM is an LLM that can generate text from a prompt made of text,
+// images and other files.
+type MultimodalLLM[TParams any] interface {
+	LLM[TParams]
+	// GenerateMultimodal generates text from the given parts and params.
+	GenerateMultimodal(ctx context.Context, parts []Part, params TParams) (Result, error)
+}
+
+// GenerateMultimodal calls GenerateMultimodal if the LLM implements
+// MultimodalLLM. Otherwise, a prompt that is only made of text is joined and
+// sent to GenerateWithMetadata, while any other prompt returns
+// ErrMultimodalUnsupported.
+func GenerateMultimodal[TParams any](ctx context.Context, llm LLM[TParams], parts []Part, params TParams) (Result, error) {
+	if m, ok := llm.(MultimodalLLM[TParams]); ok {
+		return m.GenerateMultimodal(ctx, parts, params)
+	}
+
+	prompt, ok := JoinTextParts(parts)
+	if !ok {
+		return Result{}, ErrMultimodalUnsupported
+	}
+	return GenerateWithMetadata(ctx, llm, prompt, params)
+}
+
+// JoinTextParts joins the text of the parts. It returns false if any of the
+// parts is not text.
+func JoinTextParts(parts []Part) (string, bool) {
+	var b strings.Builder
+	for _, p := range parts {
+		if !p.IsText() {
+			return "", false
+		}
+		b.WriteString(p.Text)
+	}
+	return b.String(), true
+}
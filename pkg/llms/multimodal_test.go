@@ -0,0 +1,47 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-react/pkg/llms"
+)
+
+func TestGenerateMultimodal(t *testing.T) {
+	t.Parallel()
+
+	// LLMs that only take text can still be given text parts.
+	result, err := llms.GenerateMultimodal[int](context.Background(), echo{}, []llms.Part{
+		llms.TextPart("some-"),
+		llms.TextPart("prompt"),
+	}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := result.Text, "some-prompt"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+
+	_, err = llms.GenerateMultimodal[int](context.Background(), echo{}, []llms.Part{
+		llms.TextPart("some-prompt"),
+		llms.FilePart("image/png", "gs://some-bucket/some-image.png"),
+	}, 1)
+	if actual, expected := errors.Is(err, llms.ErrMultimodalUnsupported), true; actual != expected {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+}
@@ -0,0 +1,333 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ollama is a client for running local models with an Ollama server.
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-react/pkg/llms"
+)
+
+// DefaultBaseURL is the address an Ollama server listens on by default.
+const DefaultBaseURL = "http://localhost:11434"
+
+// Params are the parameters for a request.
+type Params struct {
+	Model string
+	// NumCtx is the size of the context window. The model's default is used
+	// when it is zero.
+	NumCtx int
+	// Temperature is always sent, so the zero value means greedy decoding.
+	Temperature float64
+	// NumPredict, TopK, TopP and Seed are only sent when they are not zero.
+	NumPredict int
+	TopK       int
+	TopP       float64
+	Seed       int
+	Stop       []string
+	// Format is set to "json" to constrain the output to valid JSON.
+	Format string
+	// Schema is a JSON schema the output is constrained to. It takes
+	// precedence over Format.
+	Schema json.RawMessage
+}
+
+// FromGenerationConfig converts the common generation params into Params.
+func FromGenerationConfig(c llms.GenerationConfig) Params {
+	p := Params{
+		Model:       c.Model,
+		Temperature: c.Temperature,
+		NumPredict:  c.MaxTokens,
+		TopK:        c.TopK,
+		TopP:        c.TopP,
+		Seed:        c.Seed,
+		Stop:        c.StopSequences,
+	}
+	if c.JSON {
+		p.Format = "json"
+	}
+	return p
+}
+
+// Option is an option for New and NewChat.
+type Option func(*client)
+
+// WithBaseURL sets the base URL of the Ollama server. It defaults to
+// DefaultBaseURL.
+func WithBaseURL(baseURL string) Option {
+	return func(c *client) {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithHTTPClient sets the http.Client used to send requests. It defaults to
+// http.DefaultClient.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *client) {
+		c.client = httpClient
+	}
+}
+
+// New returns a new Ollama LLM.
+func New(opts ...Option) llms.LLM[Params] {
+	return newClient(opts)
+}
+
+// NewChat returns a new Ollama chat LLM.
+func NewChat(opts ...Option) llms.ChatLLM[Params] {
+	return newClient(opts)
+}
+
+func newClient(opts []Option) client {
+	c := client{
+		baseURL: DefaultBaseURL,
+		client:  http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+type client struct {
+	baseURL string
+	client  *http.Client
+}
+
+// Generate implements llms.LLM.
+func (c client) Generate(ctx context.Context, prompt string, params Params) (string, error) {
+	resp, err := c.send(ctx, "/api/generate", newGenerateRequest(prompt, params, false))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var r response
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return "", fmt.Errorf("failed to decode response: %v", err)
+	}
+	return r.Response, nil
+}
+
+// GenerateStream implements llms.StreamLLM.
+func (c client) GenerateStream(ctx context.Context, prompt string, params Params) (llms.Stream, error) {
+	resp, err := c.send(ctx, "/api/generate", newGenerateRequest(prompt, params, true))
+	if err != nil {
+		return nil, err
+	}
+	return &stream{
+		body: resp.Body,
+		dec:  json.NewDecoder(resp.Body),
+	}, nil
+}
+
+// stream decodes the newline delimited JSON objects that are streamed back.
+type stream struct {
+	body io.ReadCloser
+	dec  *json.Decoder
+	done bool
+}
+
+// Recv implements llms.Stream.
+func (s *stream) Recv() (string, error) {
+	for !s.done {
+		var r response
+		if err := s.dec.Decode(&r); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return "", fmt.Errorf("failed to decode response: %v", err)
+		}
+		if r.Error != "" {
+			// The request was accepted, so the error is a failure of the
+			// server while generating (e.g., the model failed to load).
+			return "", fmt.Errorf("%w: failed to generate: %s", llms.ErrTransient, r.Error)
+		}
+		s.done = r.Done
+		if r.Response != "" {
+			return r.Response, nil
+		}
+	}
+	return "", io.EOF
+}
+
+// Close implements llms.Stream.
+func (s *stream) Close() error {
+	return s.body.Close()
+}
+
+// Chat implements llms.ChatLLM.
+func (c client) Chat(ctx context.Context, messages []llms.Message, params Params) (llms.Message, error) {
+	r := chatRequest{
+		Model:   params.Model,
+		Format:  format(params),
+		Options: newOptions(params),
+	}
+	for _, m := range messages {
+		r.Messages = append(r.Messages, message{
+			Role:    string(m.Role),
+			Content: m.Content,
+		})
+	}
+
+	resp, err := c.send(ctx, "/api/chat", r)
+	if err != nil {
+		return llms.Message{}, err
+	}
+	defer resp.Body.Close()
+
+	var cr chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&cr); err != nil {
+		return llms.Message{}, fmt.Errorf("failed to decode response: %v", err)
+	}
+	return llms.Message{
+		Role:    llms.RoleAssistant,
+		Content: cr.Message.Content,
+	}, nil
+}
+
+// send sends the request and returns the response if it was successful. The
+// caller is responsible for closing the response body.
+func (c client) send(ctx context.Context, path string, r any) (*http.Response, error) {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to send request: %w", llms.ErrTransient, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, newAPIError(resp)
+	}
+	return resp, nil
+}
+
+// newAPIError reads the error from the response body and classifies it using
+// the status code.
+func newAPIError(resp *http.Response) error {
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%w: failed to read response: %v", llms.ErrTransient, err)
+	}
+
+	apiErr := &llms.APIError{
+		StatusCode: resp.StatusCode,
+		Message:    string(data),
+		RetryAfter: llms.ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		Err:        llms.ClassifyStatus(resp.StatusCode),
+	}
+
+	var body struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(data, &body); err == nil && body.Error != "" {
+		apiErr.Message = body.Error
+	}
+	return apiErr
+}
+
+func newGenerateRequest(prompt string, params Params, stream bool) generateRequest {
+	return generateRequest{
+		Model:   params.Model,
+		Prompt:  prompt,
+		Stream:  stream,
+		Format:  format(params),
+		Options: newOptions(params),
+	}
+}
+
+func newOptions(params Params) options {
+	return options{
+		NumCtx:      params.NumCtx,
+		Temperature: params.Temperature,
+		NumPredict:  params.NumPredict,
+		TopK:        params.TopK,
+		TopP:        params.TopP,
+		Seed:        params.Seed,
+		Stop:        params.Stop,
+	}
+}
+
+// format returns the value of the format field, which is either the string
+// "json" or a JSON schema.
+func format(params Params) json.RawMessage {
+	if len(params.Schema) > 0 {
+		return params.Schema
+	}
+	if params.Format != "" {
+		b, _ := json.Marshal(params.Format)
+		return b
+	}
+	return nil
+}
+
+type generateRequest struct {
+	Model   string          `json:"model"`
+	Prompt  string          `json:"prompt"`
+	Stream  bool            `json:"stream"`
+	Format  json.RawMessage `json:"format,omitempty"`
+	Options options         `json:"options"`
+}
+
+type chatRequest struct {
+	Model    string          `json:"model"`
+	Messages []message       `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Format   json.RawMessage `json:"format,omitempty"`
+	Options  options         `json:"options"`
+}
+
+type options struct {
+	NumCtx      int      `json:"num_ctx,omitempty"`
+	Temperature float64  `json:"temperature"`
+	NumPredict  int      `json:"num_predict,omitempty"`
+	TopK        int      `json:"top_k,omitempty"`
+	TopP        float64  `json:"top_p,omitempty"`
+	Seed        int      `json:"seed,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type response struct {
+	Response string `json:"response"`
+	Done     bool   `json:"done"`
+	Error    string `json:"error"`
+}
+
+type chatResponse struct {
+	Message message `json:"message"`
+}
@@ -0,0 +1,301 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ollama_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-react/pkg/llms"
+	"github.com/google/go-react/pkg/llms/ollama"
+	"github.com/google/go-react/pkg/parsers"
+	"github.com/google/go-react/pkg/predictors"
+	"github.com/google/go-react/pkg/prompters"
+)
+
+func newTestServer(t *testing.T, h http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	var body map[string]any
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if actual, expected := r.URL.Path, "/api/generate"; actual != expected {
+			t.Errorf("expected %q, got %q", expected, actual)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		fmt.Fprint(w, `{"model": "some-model", "response": "some-response", "done": true}`)
+	})
+
+	llm := ollama.New(ollama.WithBaseURL(srv.URL))
+	resp, err := llm.Generate(context.Background(), "some-prompt", ollama.Params{
+		Model:       "some-model",
+		NumCtx:      4096,
+		Temperature: 0.5,
+		Stop:        []string{"\n"},
+		Format:      "json",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := resp, "some-response"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+
+	if actual, expected := body["prompt"], "some-prompt"; actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+	if actual, expected := body["stream"], false; actual != expected {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+	if actual, expected := body["format"], "json"; actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+	options := body["options"].(map[string]any)
+	if actual, expected := options["num_ctx"], 4096.0; actual != expected {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+	if actual, expected := options["temperature"], 0.5; actual != expected {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+	if actual, expected := options["stop"].([]any)[0], "\n"; actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestGenerate_schema(t *testing.T) {
+	t.Parallel()
+
+	var body map[string]any
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		fmt.Fprint(w, `{"response": "{}", "done": true}`)
+	})
+
+	llm := ollama.New(ollama.WithBaseURL(srv.URL))
+	if _, err := llm.Generate(context.Background(), "some-prompt", ollama.Params{
+		Model:  "some-model",
+		Format: "json",
+		Schema: json.RawMessage(`{"type": "object"}`),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if actual, expected := body["format"].(map[string]any)["type"], "object"; actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestGenerate_error(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error": "model 'some-model' not found"}`)
+	})
+
+	llm := ollama.New(ollama.WithBaseURL(srv.URL))
+	_, err := llm.Generate(context.Background(), "some-prompt", ollama.Params{Model: "some-model"})
+	if actual, expected := errors.Is(err, llms.ErrInvalidRequest), true; actual != expected {
+		t.Fatalf("expected %v, got %v: %v", expected, actual, err)
+	}
+	if actual, expected := err.Error(), "request failed with status code 404: model 'some-model' not found"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestGenerate_canceled(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"response": "some-response", "done": true}`)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	llm := ollama.New(ollama.WithBaseURL(srv.URL))
+	_, err := llm.Generate(ctx, "some-prompt", ollama.Params{Model: "some-model"})
+	if actual, expected := errors.Is(err, context.Canceled), true; actual != expected {
+		t.Fatalf("expected %v, got %v: %v", expected, actual, err)
+	}
+	if actual, expected := llms.IsRetryable(err), false; actual != expected {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestGenerateStream(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		for _, chunk := range []string{"some-", "streamed-", "response"} {
+			fmt.Fprintf(w, "{\"response\": %q, \"done\": false}\n", chunk)
+			w.(http.Flusher).Flush()
+		}
+		fmt.Fprint(w, `{"response": "", "done": true}`+"\n")
+	})
+
+	llm := ollama.New(ollama.WithBaseURL(srv.URL))
+	s, err := llms.GenerateStream(context.Background(), llm, "some-prompt", ollama.Params{Model: "some-model"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := llms.ReadStream(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := resp, "some-streamed-response"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestGenerateStream_error(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"response": "some-", "done": false}`)
+		fmt.Fprintln(w, `{"error": "some-error"}`)
+	})
+
+	llm := ollama.New(ollama.WithBaseURL(srv.URL))
+	s, err := llms.GenerateStream(context.Background(), llm, "some-prompt", ollama.Params{Model: "some-model"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = llms.ReadStream(s)
+	if actual, expected := errors.Is(err, llms.ErrTransient), true; actual != expected {
+		t.Fatalf("expected %v, got %v: %v", expected, actual, err)
+	}
+}
+
+func TestChat(t *testing.T) {
+	t.Parallel()
+
+	var body struct {
+		Messages []llms.Message `json:"messages"`
+	}
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if actual, expected := r.URL.Path, "/api/chat"; actual != expected {
+			t.Errorf("expected %q, got %q", expected, actual)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		fmt.Fprint(w, `{"message": {"role": "assistant", "content": "some-response"}, "done": true}`)
+	})
+
+	chat := ollama.NewChat(ollama.WithBaseURL(srv.URL))
+	resp, err := chat.Chat(context.Background(), []llms.Message{
+		{Role: llms.RoleSystem, Content: "some-instructions"},
+		{Role: llms.RoleUser, Content: "some-question"},
+	}, ollama.Params{Model: "some-model"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := resp, (llms.Message{Role: llms.RoleAssistant, Content: "some-response"}); actual != expected {
+		t.Fatalf("expected %+v, got %+v", expected, actual)
+	}
+	if actual, expected := body.Messages[0], (llms.Message{Role: llms.RoleSystem, Content: "some-instructions"}); actual != expected {
+		t.Fatalf("expected %+v, got %+v", expected, actual)
+	}
+}
+
+func TestPredictor(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"response": "{\"name\": \"some-name\"}", "done": true}`)
+	})
+
+	type Data struct {
+		Name string `json:"name"`
+	}
+	predictor := predictors.New(
+		ollama.New(ollama.WithBaseURL(srv.URL)),
+		prompters.NewTextTemplate[string](`Name something: {{.}}`, ollama.Params{Model: "some-model", Format: "json"}),
+		parsers.NewJSONParser[Data](),
+	)
+	resp, err := predictor.Predict(context.Background(), "some-thing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := resp.Name, "some-name"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestFromGenerationConfig(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		config   llms.GenerationConfig
+		expected ollama.Params
+	}{
+		{
+			name: "text",
+			config: llms.GenerationConfig{
+				Model:         "some-model",
+				Temperature:   0.5,
+				TopK:          40,
+				TopP:          0.9,
+				MaxTokens:     10,
+				StopSequences: []string{"\n"},
+				Seed:          7,
+			},
+			expected: ollama.Params{
+				Model:       "some-model",
+				Temperature: 0.5,
+				TopK:        40,
+				TopP:        0.9,
+				NumPredict:  10,
+				Stop:        []string{"\n"},
+				Seed:        7,
+			},
+		},
+		{
+			name:     "json",
+			config:   llms.GenerationConfig{Model: "some-model", JSON: true},
+			expected: ollama.Params{Model: "some-model", Format: "json"},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if actual, expected := ollama.FromGenerationConfig(tc.config), tc.expected; !reflect.DeepEqual(actual, expected) {
+				t.Fatalf("expected %+v, got %+v", expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,300 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package openai is a client for OpenAI compatible chat completions APIs
+// (e.g., OpenAI, vLLM and LiteLLM).
+package openai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-react/pkg/llms"
+)
+
+// DefaultBaseURL is the base URL of the OpenAI API.
+const DefaultBaseURL = "https://api.openai.com/v1"
+
+// Params are the parameters for a request.
+type Params struct {
+	Model string
+	// Temperature is always sent, so the zero value means greedy decoding.
+	Temperature float64
+	// MaxTokens, TopP and Seed are only sent when they are not zero.
+	MaxTokens int
+	TopP      float64
+	Seed      int
+	Stop      []string
+	// ResponseFormat is either "text" or "json_object". It is only sent when it
+	// is set.
+	ResponseFormat string
+}
+
+// FromGenerationConfig converts the common generation params into Params. The
+// API doesn't support TopK, so it is ignored.
+func FromGenerationConfig(c llms.GenerationConfig) Params {
+	p := Params{
+		Model:       c.Model,
+		Temperature: c.Temperature,
+		MaxTokens:   c.MaxTokens,
+		TopP:        c.TopP,
+		Seed:        c.Seed,
+		Stop:        c.StopSequences,
+	}
+	if c.JSON {
+		p.ResponseFormat = "json_object"
+	}
+	return p
+}
+
+// Option is an option for New and NewChat.
+type Option func(*client)
+
+// WithBaseURL sets the base URL of the API (e.g., http://localhost:8000/v1).
+// It defaults to DefaultBaseURL.
+func WithBaseURL(baseURL string) Option {
+	return func(c *client) {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithHTTPClient sets the http.Client used to send requests. It defaults to
+// http.DefaultClient.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *client) {
+		c.client = httpClient
+	}
+}
+
+// WithHeader sets an extra header that is sent with each request.
+func WithHeader(key, value string) Option {
+	return func(c *client) {
+		c.headers.Set(key, value)
+	}
+}
+
+// New returns a new OpenAI compatible LLM. Each prompt is sent as a single
+// user message.
+func New(apiKey string, opts ...Option) llms.LLM[Params] {
+	return newClient(apiKey, opts)
+}
+
+// NewChat returns a new OpenAI compatible chat LLM.
+func NewChat(apiKey string, opts ...Option) llms.ChatLLM[Params] {
+	return newClient(apiKey, opts)
+}
+
+func newClient(apiKey string, opts []Option) client {
+	c := client{
+		key:     apiKey,
+		baseURL: DefaultBaseURL,
+		client:  http.DefaultClient,
+		headers: http.Header{},
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+type client struct {
+	key     string
+	baseURL string
+	client  *http.Client
+	headers http.Header
+}
+
+// Generate implements llms.LLM.
+func (c client) Generate(ctx context.Context, prompt string, params Params) (string, error) {
+	m, err := c.Chat(ctx, []llms.Message{{Role: llms.RoleUser, Content: prompt}}, params)
+	if err != nil {
+		return "", err
+	}
+	return m.Content, nil
+}
+
+// Chat implements llms.ChatLLM.
+func (c client) Chat(ctx context.Context, messages []llms.Message, params Params) (llms.Message, error) {
+	resp, err := c.send(ctx, newRequest(messages, params, false))
+	if err != nil {
+		return llms.Message{}, err
+	}
+	defer resp.Body.Close()
+
+	var r response
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return llms.Message{}, fmt.Errorf("failed to decode response: %v", err)
+	}
+	if len(r.Choices) == 0 {
+		return llms.Message{}, fmt.Errorf("no choices returned")
+	}
+
+	return llms.Message{
+		Role:    llms.RoleAssistant,
+		Content: r.Choices[0].Message.Content,
+	}, nil
+}
+
+// GenerateStream implements llms.StreamLLM.
+func (c client) GenerateStream(ctx context.Context, prompt string, params Params) (llms.Stream, error) {
+	resp, err := c.send(ctx, newRequest([]llms.Message{{Role: llms.RoleUser, Content: prompt}}, params, true))
+	if err != nil {
+		return nil, err
+	}
+	return &stream{
+		body:    resp.Body,
+		scanner: bufio.NewScanner(resp.Body),
+	}, nil
+}
+
+// stream reads the server-sent events of a streamed chat completion.
+type stream struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+	done    bool
+}
+
+// Recv implements llms.Stream.
+func (s *stream) Recv() (string, error) {
+	for !s.done && s.scanner.Scan() {
+		data, ok := strings.CutPrefix(s.scanner.Text(), "data:")
+		if !ok {
+			// Skip blank lines and other fields (e.g., comments).
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			s.done = true
+			break
+		}
+
+		var r response
+		if err := json.Unmarshal([]byte(data), &r); err != nil {
+			return "", fmt.Errorf("failed to decode response: %v", err)
+		}
+		if len(r.Choices) > 0 && r.Choices[0].Delta.Content != "" {
+			return r.Choices[0].Delta.Content, nil
+		}
+	}
+	if err := s.scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read response: %v", err)
+	}
+	return "", io.EOF
+}
+
+// Close implements llms.Stream.
+func (s *stream) Close() error {
+	return s.body.Close()
+}
+
+// send sends the request and returns the response if it was successful. The
+// caller is responsible for closing the response body.
+func (c client) send(ctx context.Context, r request) (*http.Response, error) {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	for k, v := range c.headers {
+		req.Header[k] = v
+	}
+	if c.key != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.key))
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to send request: %w", llms.ErrTransient, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, newAPIError(resp)
+	}
+	return resp, nil
+}
+
+func newRequest(messages []llms.Message, params Params, stream bool) request {
+	r := request{
+		Model:       params.Model,
+		Temperature: params.Temperature,
+		MaxTokens:   params.MaxTokens,
+		TopP:        params.TopP,
+		Stop:        params.Stop,
+		Stream:      stream,
+	}
+	if params.Seed != 0 {
+		r.Seed = &params.Seed
+	}
+	if params.ResponseFormat != "" {
+		r.ResponseFormat = &responseFormat{Type: params.ResponseFormat}
+	}
+	for _, m := range messages {
+		if m.Role == llms.RoleTool {
+			// Tool messages must answer a tool call, so tool output is sent as
+			// if the user wrote it.
+			r.Messages = append(r.Messages, message{
+				Role:    string(llms.RoleUser),
+				Content: m.Content,
+			})
+			continue
+		}
+		r.Messages = append(r.Messages, message{
+			Role:    string(m.Role),
+			Content: m.Content,
+			Name:    m.Name,
+		})
+	}
+	return r
+}
+
+type request struct {
+	Model          string          `json:"model"`
+	Messages       []message       `json:"messages"`
+	Temperature    float64         `json:"temperature"`
+	MaxTokens      int             `json:"max_tokens,omitempty"`
+	TopP           float64         `json:"top_p,omitempty"`
+	Seed           *int            `json:"seed,omitempty"`
+	Stop           []string        `json:"stop,omitempty"`
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
+	Stream         bool            `json:"stream,omitempty"`
+}
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+	Name    string `json:"name,omitempty"`
+}
+
+type responseFormat struct {
+	Type string `json:"type"`
+}
+
+type response struct {
+	Choices []struct {
+		Message message `json:"message"`
+		Delta   message `json:"delta"`
+	} `json:"choices"`
+}
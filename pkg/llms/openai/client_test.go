@@ -0,0 +1,349 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/go-react/pkg/llms"
+	"github.com/google/go-react/pkg/llms/openai"
+)
+
+func newTestServer(t *testing.T, h http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	var body map[string]any
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if actual, expected := r.URL.Path, "/v1/chat/completions"; actual != expected {
+			t.Errorf("expected %q, got %q", expected, actual)
+		}
+		if actual, expected := r.Header.Get("Authorization"), "Bearer some-key"; actual != expected {
+			t.Errorf("expected %q, got %q", expected, actual)
+		}
+		if actual, expected := r.Header.Get("X-Some-Header"), "some-value"; actual != expected {
+			t.Errorf("expected %q, got %q", expected, actual)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		fmt.Fprint(w, `{"choices": [{"message": {"role": "assistant", "content": "some-response"}}]}`)
+	})
+
+	llm := openai.New(
+		"some-key",
+		openai.WithBaseURL(srv.URL+"/v1/"),
+		openai.WithHTTPClient(srv.Client()),
+		openai.WithHeader("X-Some-Header", "some-value"),
+	)
+	resp, err := llm.Generate(context.Background(), "some-prompt", openai.Params{
+		Model:          "some-model",
+		MaxTokens:      10,
+		Seed:           7,
+		Stop:           []string{"\n"},
+		ResponseFormat: "json_object",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := resp, "some-response"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+
+	if actual, expected := body["model"], "some-model"; actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+	if actual, expected := body["temperature"], 0.0; actual != expected {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+	if actual, expected := body["max_tokens"], 10.0; actual != expected {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+	if actual, expected := body["seed"], 7.0; actual != expected {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+	if _, ok := body["top_p"]; ok {
+		t.Errorf("expected top_p to be omitted")
+	}
+	if actual, expected := body["response_format"].(map[string]any)["type"], "json_object"; actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+	message := body["messages"].([]any)[0].(map[string]any)
+	if actual, expected := message["role"], "user"; actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+	if actual, expected := message["content"], "some-prompt"; actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestChat(t *testing.T) {
+	t.Parallel()
+
+	var body struct {
+		Messages []llms.Message `json:"messages"`
+	}
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		fmt.Fprint(w, `{"choices": [{"message": {"role": "assistant", "content": "some-response"}}]}`)
+	})
+
+	messages := []llms.Message{
+		{Role: llms.RoleSystem, Content: "some-instructions"},
+		{Role: llms.RoleUser, Content: "some-question"},
+		{Role: llms.RoleAssistant, Content: "some-answer"},
+	}
+	chat := openai.NewChat("some-key", openai.WithBaseURL(srv.URL))
+	resp, err := chat.Chat(context.Background(), messages, openai.Params{Model: "some-model"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := resp, (llms.Message{Role: llms.RoleAssistant, Content: "some-response"}); actual != expected {
+		t.Fatalf("expected %+v, got %+v", expected, actual)
+	}
+	if actual, expected := len(body.Messages), len(messages); actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+	for i := range messages {
+		if actual, expected := body.Messages[i], messages[i]; actual != expected {
+			t.Errorf("expected %+v, got %+v", expected, actual)
+		}
+	}
+}
+
+func TestChat_tool(t *testing.T) {
+	t.Parallel()
+
+	var body map[string]any
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		fmt.Fprint(w, `{"choices": [{"message": {"role": "assistant", "content": "some-response"}}]}`)
+	})
+
+	chat := openai.NewChat("some-key", openai.WithBaseURL(srv.URL))
+	if _, err := chat.Chat(context.Background(), []llms.Message{
+		{Role: llms.RoleUser, Content: "some-question"},
+		{Role: llms.RoleAssistant, Content: "some-action"},
+		{Role: llms.RoleTool, Name: "some tool", Content: "some-output"},
+	}, openai.Params{Model: "some-model"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The tool output is sent as a user message without a name, since the API
+	// only accepts tool messages that answer a tool call.
+	expected := map[string]any{"role": "user", "content": "some-output"}
+	if actual := body["messages"].([]any)[2]; !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, actual)
+	}
+}
+
+func TestGenerateStream(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		if actual, expected := body["stream"], true; actual != expected {
+			t.Errorf("expected %v, got %v", expected, actual)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range []string{"some-", "streamed-", "response"} {
+			fmt.Fprintf(w, "data: {\"choices\": [{\"delta\": {\"content\": %q}}]}\n\n", chunk)
+			w.(http.Flusher).Flush()
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	llm := openai.New("some-key", openai.WithBaseURL(srv.URL))
+	s, err := llms.GenerateStream(context.Background(), llm, "some-prompt", openai.Params{Model: "some-model"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := llms.ReadStream(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := resp, "some-streamed-response"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestGenerate_errors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		status     int
+		retryAfter string
+		body       string
+		expected   error
+	}{
+		{
+			name:     "bad request",
+			status:   http.StatusBadRequest,
+			body:     `{"error": {"message": "some-error", "type": "invalid_request_error", "param": "model", "code": null}}`,
+			expected: openai.ErrInvalidRequest,
+		},
+		{
+			name:     "context length exceeded",
+			status:   http.StatusBadRequest,
+			body:     `{"error": {"message": "some-error", "type": "invalid_request_error", "code": "context_length_exceeded"}}`,
+			expected: openai.ErrContextLengthExceeded,
+		},
+		{
+			name:     "unauthorized",
+			status:   http.StatusUnauthorized,
+			body:     `{"error": {"message": "some-error", "type": "invalid_request_error", "code": "invalid_api_key"}}`,
+			expected: openai.ErrAuthentication,
+		},
+		{
+			name:       "rate limited",
+			status:     http.StatusTooManyRequests,
+			retryAfter: "7",
+			body:       `{"error": {"message": "some-error", "type": "requests", "code": "rate_limit_exceeded"}}`,
+			expected:   openai.ErrRateLimited,
+		},
+		{
+			name:     "server error from a gateway",
+			status:   http.StatusBadGateway,
+			body:     `some-gateway-error`,
+			expected: openai.ErrServer,
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if tc.retryAfter != "" {
+					w.Header().Set("Retry-After", tc.retryAfter)
+				}
+				w.WriteHeader(tc.status)
+				fmt.Fprint(w, tc.body)
+			})
+
+			llm := openai.New("some-key", openai.WithBaseURL(srv.URL))
+			_, err := llm.Generate(context.Background(), "some-prompt", openai.Params{Model: "some-model"})
+			if actual, expected := errors.Is(err, tc.expected), true; actual != expected {
+				t.Fatalf("expected %v, got %v: %v", expected, actual, err)
+			}
+
+			var apiErr *llms.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected an APIError, got %T", err)
+			}
+			if actual, expected := apiErr.StatusCode, tc.status; actual != expected {
+				t.Fatalf("expected %d, got %d", expected, actual)
+			}
+
+			wait, _ := llms.RetryAfter(err)
+			if actual, expected := wait, llms.ParseRetryAfter(tc.retryAfter, time.Now()); actual != expected {
+				t.Fatalf("expected %v, got %v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestGenerate_canceled(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("unexpected request")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	llm := openai.New("some-key", openai.WithBaseURL(srv.URL))
+	_, err := llm.Generate(ctx, "some-prompt", openai.Params{Model: "some-model"})
+	if actual, expected := errors.Is(err, context.Canceled), true; actual != expected {
+		t.Fatalf("expected %v, got %v: %v", expected, actual, err)
+	}
+	if actual, expected := llms.IsRetryable(err), false; actual != expected {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestFromGenerationConfig(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		config   llms.GenerationConfig
+		expected openai.Params
+	}{
+		{
+			name: "text",
+			config: llms.GenerationConfig{
+				Model:         "some-model",
+				Temperature:   0.5,
+				TopK:          40,
+				TopP:          0.9,
+				MaxTokens:     10,
+				StopSequences: []string{"\n"},
+				Seed:          7,
+			},
+			expected: openai.Params{
+				Model:       "some-model",
+				Temperature: 0.5,
+				TopP:        0.9,
+				MaxTokens:   10,
+				Stop:        []string{"\n"},
+				Seed:        7,
+			},
+		},
+		{
+			name:     "json",
+			config:   llms.GenerationConfig{Model: "some-model", JSON: true},
+			expected: openai.Params{Model: "some-model", ResponseFormat: "json_object"},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if actual, expected := openai.FromGenerationConfig(tc.config), tc.expected; !reflect.DeepEqual(actual, expected) {
+				t.Fatalf("expected %+v, got %+v", expected, actual)
+			}
+		})
+	}
+}
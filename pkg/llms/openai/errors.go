@@ -0,0 +1,76 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/go-react/pkg/llms"
+)
+
+// The package's errors are the llms errors, so they work with llms.IsRetryable.
+var (
+	// ErrInvalidRequest is returned when the request was rejected as invalid
+	// (e.g., an unknown model or a malformed parameter).
+	ErrInvalidRequest = llms.ErrInvalidRequest
+	// ErrContextLengthExceeded is returned when the prompt and requested tokens
+	// do not fit in the model's context window.
+	ErrContextLengthExceeded = llms.ErrContextLength
+	// ErrAuthentication is returned when the API key is missing or invalid.
+	ErrAuthentication = llms.ErrAuth
+	// ErrRateLimited is returned when the rate limit or quota is exceeded.
+	ErrRateLimited = llms.ErrRateLimited
+	// ErrServer is returned when the server failed to handle the request.
+	ErrServer = llms.ErrTransient
+)
+
+// newAPIError reads the error from the response body and classifies it using
+// the status code. The error's code (e.g., rate_limit_exceeded) is used as the
+// status. Gateways don't always respond with the OpenAI error format, so the
+// raw body is used as the message when it can't be decoded.
+func newAPIError(resp *http.Response) error {
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%w: failed to read response: %v", llms.ErrTransient, err)
+	}
+
+	apiErr := &llms.APIError{
+		StatusCode: resp.StatusCode,
+		Message:    string(data),
+		RetryAfter: llms.ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		Err:        llms.ClassifyStatus(resp.StatusCode),
+	}
+
+	var body struct {
+		Error *struct {
+			Message string `json:"message"`
+			Code    any    `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &body); err == nil && body.Error != nil {
+		apiErr.Message = body.Error.Message
+		if body.Error.Code != nil {
+			apiErr.Status = fmt.Sprint(body.Error.Code)
+		}
+	}
+	if apiErr.Status == "context_length_exceeded" {
+		apiErr.Err = ErrContextLengthExceeded
+	}
+	return apiErr
+}
@@ -0,0 +1,92 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// FinishReason is the reason the LLM stopped generating.
+type FinishReason string
+
+const (
+	// FinishReasonUnknown is used when the LLM does not report a reason.
+	FinishReasonUnknown FinishReason = ""
+	// FinishReasonStop is used when the LLM finished naturally or hit a stop
+	// sequence.
+	FinishReasonStop FinishReason = "stop"
+	// FinishReasonMaxTokens is used when the response was truncated because it
+	// hit the maximum number of tokens.
+	FinishReasonMaxTokens FinishReason = "max_tokens"
+	// FinishReasonSafety is used when the response was blocked by the safety
+	// filters.
+	FinishReasonSafety FinishReason = "safety"
+	// FinishReasonOther is used for any other reason the LLM reports.
+	FinishReasonOther FinishReason = "other"
+)
+
+// Usage is the number of tokens used by a request.
+type Usage struct {
+	InputTokens  int `json:"inputTokens"`
+	OutputTokens int `json:"outputTokens"`
+}
+
+// SafetyRating is how likely the response is to be harmful for a category.
+type SafetyRating struct {
+	Category string `json:"category"`
+	// Probability is the provider specific probability (e.g., NEGLIGIBLE or
+	// 0.1).
+	Probability string `json:"probability"`
+	Blocked     bool   `json:"blocked,omitempty"`
+}
+
+// Result is the generated text along with the metadata the LLM reported about
+// it. Fields that the LLM does not report are left empty.
+type Result struct {
+	Text          string         `json:"text"`
+	Usage         Usage          `json:"usage"`
+	FinishReason  FinishReason   `json:"finishReason,omitempty"`
+	SafetyRatings []SafetyRating `json:"safetyRatings,omitempty"`
+	// FunctionCalls are the calls the LLM asked for (see FunctionCallingLLM).
+	FunctionCalls []FunctionCall `json:"functionCalls,omitempty"`
+	// Raw is the provider's response.
+	Raw json.RawMessage `json:"raw,omitempty"`
+	// Cached is whether the result was served from a cache (see NewCache).
+	// Its Usage is then zero since no tokens were used for it.
+	Cached bool `json:"cached,omitempty"`
+}
+
+// MetadataLLM is an LLM that can report metadata about the generated text.
+type MetadataLLM[TParams any] interface {
+	LLM[TParams]
+	// GenerateWithMetadata generates text from the given prompt and params and
+	// returns it with its metadata.
+	GenerateWithMetadata(ctx context.Context, prompt string, params TParams) (Result, error)
+}
+
+// GenerateWithMetadata returns the response with its metadata if the LLM
+// implements MetadataLLM. Otherwise, it falls back to Generate and returns a
+// Result with only the text.
+func GenerateWithMetadata[TParams any](ctx context.Context, llm LLM[TParams], prompt string, params TParams) (Result, error) {
+	if m, ok := llm.(MetadataLLM[TParams]); ok {
+		return m.GenerateWithMetadata(ctx, prompt, params)
+	}
+	resp, err := llm.Generate(ctx, prompt, params)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Text: resp}, nil
+}
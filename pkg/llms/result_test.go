@@ -0,0 +1,81 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-react/pkg/llms"
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+)
+
+// textOnly hides the optional interfaces of the LLM it wraps.
+type textOnly struct {
+	llms.LLM[int]
+}
+
+func TestGenerateWithMetadata(t *testing.T) {
+	t.Parallel()
+
+	result := llms.Result{
+		Text:         "some-response",
+		Usage:        llms.Usage{InputTokens: 3, OutputTokens: 5},
+		FinishReason: llms.FinishReasonMaxTokens,
+	}
+
+	testCases := []struct {
+		name     string
+		llm      func(*llmstesting.Fake[int]) llms.LLM[int]
+		expected llms.Result
+	}{
+		{
+			name:     "metadata LLM",
+			llm:      func(f *llmstesting.Fake[int]) llms.LLM[int] { return f },
+			expected: result,
+		},
+		{
+			name:     "falls back to Generate",
+			llm:      func(f *llmstesting.Fake[int]) llms.LLM[int] { return textOnly{f} },
+			expected: llms.Result{Text: "some-response"},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := &llmstesting.Fake[int]{
+				Outputs: map[string]string{"some-prompt": "some-response"},
+				Results: map[string]llms.Result{"some-prompt": result},
+			}
+			actual, err := llms.GenerateWithMetadata(context.Background(), tc.llm(fake), "some-prompt", 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual, expected := actual.Text, tc.expected.Text; actual != expected {
+				t.Fatalf("expected %q, got %q", expected, actual)
+			}
+			if actual, expected := actual.Usage, tc.expected.Usage; actual != expected {
+				t.Fatalf("expected %+v, got %+v", expected, actual)
+			}
+			if actual, expected := actual.FinishReason, tc.expected.FinishReason; actual != expected {
+				t.Fatalf("expected %q, got %q", expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// NewSlogLogger creates a logger that wraps the LLM. It logs each request to
+// the given slog.Logger with the prompt, params, response, latency and error
+// as attributes, along with the token usage and finish reason when the LLM
+// reports them. Use a logging.NewHandler to redact and truncate them.
+func NewSlogLogger[TParams any](llm LLM[TParams], logger *slog.Logger) LLM[TParams] {
+	return slogLogger[TParams]{
+		llm:    llm,
+		logger: logger,
+	}
+}
+
+type slogLogger[TParams any] struct {
+	llm    LLM[TParams]
+	logger *slog.Logger
+}
+
+// Generate implements the LLM interface.
+func (l slogLogger[TParams]) Generate(ctx context.Context, prompt string, params TParams) (string, error) {
+	result, err := l.GenerateWithMetadata(ctx, prompt, params)
+	if err != nil {
+		return "", err
+	}
+	return result.Text, nil
+}
+
+// GenerateWithMetadata implements the MetadataLLM interface.
+func (l slogLogger[TParams]) GenerateWithMetadata(ctx context.Context, prompt string, params TParams) (Result, error) {
+	start := time.Now()
+	result, err := GenerateWithMetadata(ctx, l.llm, prompt, params)
+	l.log(ctx, prompt, params, result, time.Since(start), err)
+	return result, err
+}
+
+// GenerateStream implements the StreamLLM interface. The assembled response is
+// logged once the stream has been fully read or closed.
+func (l slogLogger[TParams]) GenerateStream(ctx context.Context, prompt string, params TParams) (Stream, error) {
+	start := time.Now()
+	s, err := GenerateStream(ctx, l.llm, prompt, params)
+	if err != nil {
+		l.log(ctx, prompt, params, Result{}, time.Since(start), err)
+		return nil, err
+	}
+	return &slogLoggerStream[TParams]{
+		s:      s,
+		l:      l,
+		ctx:    ctx,
+		prompt: prompt,
+		params: params,
+		start:  start,
+	}, nil
+}
+
+func (l slogLogger[TParams]) log(ctx context.Context, prompt string, params TParams, result Result, latency time.Duration, err error) {
+	attrs := []slog.Attr{
+		slog.String("prompt", prompt),
+		slog.Any("params", params),
+		slog.String("response", result.Text),
+		slog.Duration("latency", latency),
+	}
+	if result.Usage != (Usage{}) {
+		attrs = append(attrs, slog.Group("usage",
+			slog.Int("inputTokens", result.Usage.InputTokens),
+			slog.Int("outputTokens", result.Usage.OutputTokens),
+		))
+	}
+	if result.FinishReason != FinishReasonUnknown {
+		attrs = append(attrs, slog.String("finishReason", string(result.FinishReason)))
+	}
+
+	level := slog.LevelInfo
+	if err != nil {
+		level = slog.LevelError
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	l.logger.LogAttrs(ctx, level, "llm generate", attrs...)
+}
+
+type slogLoggerStream[TParams any] struct {
+	s      Stream
+	l      slogLogger[TParams]
+	ctx    context.Context
+	prompt string
+	params TParams
+	start  time.Time
+
+	response strings.Builder
+	logged   bool
+}
+
+// Recv implements Stream.
+func (s *slogLoggerStream[TParams]) Recv() (string, error) {
+	chunk, err := s.s.Recv()
+	if errors.Is(err, io.EOF) {
+		s.log(nil)
+		return "", err
+	}
+	if err != nil {
+		s.log(err)
+		return "", err
+	}
+	s.response.WriteString(chunk)
+	return chunk, nil
+}
+
+// Close implements Stream.
+func (s *slogLoggerStream[TParams]) Close() error {
+	s.log(nil)
+	return s.s.Close()
+}
+
+func (s *slogLoggerStream[TParams]) log(err error) {
+	if s.logged {
+		return
+	}
+	s.logged = true
+	s.l.log(s.ctx, s.prompt, s.params, Result{Text: s.response.String()}, time.Since(s.start), err)
+}
@@ -0,0 +1,131 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/google/go-react/pkg/llms"
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+)
+
+func TestSlogLogger(t *testing.T) {
+	t.Parallel()
+
+	fake := &llmstesting.Fake[int]{
+		Results: map[string]llms.Result{
+			"some-prompt": {
+				Text:         "some-response",
+				Usage:        llms.Usage{InputTokens: 3, OutputTokens: 5},
+				FinishReason: llms.FinishReasonStop,
+			},
+		},
+	}
+	var buf bytes.Buffer
+	llm := llms.NewSlogLogger[int](fake, slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	resp, err := llm.Generate(context.Background(), "some-prompt", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := resp, "some-response"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+
+	var m map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := m["msg"], "llm generate"; actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+	if actual, expected := m["level"], "INFO"; actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+	if actual, expected := m["prompt"], "some-prompt"; actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+	if actual, expected := m["params"], 1.0; actual != expected {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+	if actual, expected := m["response"], "some-response"; actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+	if _, ok := m["latency"]; !ok {
+		t.Errorf("expected latency to be logged")
+	}
+	if actual, expected := m["usage"].(map[string]any)["outputTokens"], 5.0; actual != expected {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+	if actual, expected := m["finishReason"], "stop"; actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestSlogLogger_error(t *testing.T) {
+	t.Parallel()
+
+	fake := &llmstesting.Fake[int]{Err: errors.New("some-error")}
+	var buf bytes.Buffer
+	llm := llms.NewSlogLogger[int](fake, slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	if _, err := llm.Generate(context.Background(), "some-prompt", 1); err == nil {
+		t.Fatal("expected error")
+	}
+
+	var m map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := m["level"], "ERROR"; actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+	if actual, expected := m["error"], "some-error"; actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestSlogLogger_stream(t *testing.T) {
+	t.Parallel()
+
+	fake := &llmstesting.Fake[int]{
+		Chunks: map[string][]string{"some-prompt": {"some-", "response"}},
+	}
+	var buf bytes.Buffer
+	llm := llms.NewSlogLogger[int](fake, slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	s, err := llms.GenerateStream(context.Background(), llm, "some-prompt", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := llms.ReadStream(s); err != nil {
+		t.Fatal(err)
+	}
+
+	// The response is only logged once, even though the stream is closed
+	// after it was read.
+	var m map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := m["response"], "some-response"; actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+// Stream is an iterator over the chunks of text generated by an LLM.
+type Stream interface {
+	// Recv returns the next chunk of text. It returns io.EOF once the whole
+	// response has been received.
+	Recv() (string, error)
+	// Close releases any resources held by the Stream. It must be called even
+	// if Recv has returned io.EOF.
+	Close() error
+}
+
+// StreamLLM is an LLM that can return the generated text as it is produced.
+type StreamLLM[TParams any] interface {
+	LLM[TParams]
+	// GenerateStream generates text from the given prompt and params and
+	// streams it back in chunks.
+	GenerateStream(ctx context.Context, prompt string, params TParams) (Stream, error)
+}
+
+// GenerateStream streams the response from the LLM if it implements
+// StreamLLM. Otherwise, it falls back to Generate and returns the whole
+// response as a single chunk.
+func GenerateStream[TParams any](ctx context.Context, llm LLM[TParams], prompt string, params TParams) (Stream, error) {
+	if s, ok := llm.(StreamLLM[TParams]); ok {
+		return s.GenerateStream(ctx, prompt, params)
+	}
+	resp, err := llm.Generate(ctx, prompt, params)
+	if err != nil {
+		return nil, err
+	}
+	return NewStaticStream(resp), nil
+}
+
+// ReadStream reads the Stream until io.EOF and returns the assembled text. It
+// closes the Stream.
+func ReadStream(s Stream) (string, error) {
+	defer s.Close()
+
+	var b strings.Builder
+	for {
+		chunk, err := s.Recv()
+		if errors.Is(err, io.EOF) {
+			return b.String(), nil
+		}
+		if err != nil {
+			return b.String(), err
+		}
+		b.WriteString(chunk)
+	}
+}
+
+// NewStaticStream returns a Stream that returns each of the given chunks.
+func NewStaticStream(chunks ...string) Stream {
+	return &staticStream{chunks: chunks}
+}
+
+type staticStream struct {
+	chunks []string
+}
+
+// Recv implements Stream.
+func (s *staticStream) Recv() (string, error) {
+	if len(s.chunks) == 0 {
+		return "", io.EOF
+	}
+	chunk := s.chunks[0]
+	s.chunks = s.chunks[1:]
+	return chunk, nil
+}
+
+// Close implements Stream.
+func (s *staticStream) Close() error {
+	return nil
+}
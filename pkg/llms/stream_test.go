@@ -0,0 +1,131 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/go-react/pkg/llms"
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+)
+
+// generateOnly hides the GenerateStream method of the wrapped LLM.
+type generateOnly[TParams any] struct {
+	llms.LLM[TParams]
+}
+
+func TestGenerateStream(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		llm    func(*llmstesting.Fake[int]) llms.LLM[int]
+		assert func(t *testing.T, chunks []string, err error)
+	}{
+		{
+			name: "streams chunks",
+			llm: func(f *llmstesting.Fake[int]) llms.LLM[int] {
+				f.Chunks = map[string][]string{"some-prompt": {"some-", "response"}}
+				return f
+			},
+			assert: func(t *testing.T, chunks []string, err error) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := len(chunks), 2; actual != expected {
+					t.Fatalf("expected %d, got %d", expected, actual)
+				}
+				if actual, expected := chunks[0]+chunks[1], "some-response"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name: "falls back to Generate",
+			llm: func(f *llmstesting.Fake[int]) llms.LLM[int] {
+				f.AlwaysText = "some-response"
+				return generateOnly[int]{f}
+			},
+			assert: func(t *testing.T, chunks []string, err error) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := len(chunks), 1; actual != expected {
+					t.Fatalf("expected %d, got %d", expected, actual)
+				}
+				if actual, expected := chunks[0], "some-response"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name: "fallback returns error",
+			llm: func(f *llmstesting.Fake[int]) llms.LLM[int] {
+				f.Err = errors.New("some-error")
+				return generateOnly[int]{f}
+			},
+			assert: func(t *testing.T, chunks []string, err error) {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := &llmstesting.Fake[int]{}
+			s, err := llms.GenerateStream(context.Background(), tc.llm(fake), "some-prompt", 1)
+			if err != nil {
+				tc.assert(t, nil, err)
+				return
+			}
+			defer s.Close()
+
+			var chunks []string
+			for {
+				chunk, err := s.Recv()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					tc.assert(t, chunks, err)
+					return
+				}
+				chunks = append(chunks, chunk)
+			}
+			tc.assert(t, chunks, nil)
+		})
+	}
+}
+
+func TestReadStream(t *testing.T) {
+	t.Parallel()
+
+	resp, err := llms.ReadStream(llms.NewStaticStream("a", "b", "c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := resp, "abc"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+}
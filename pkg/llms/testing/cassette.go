@@ -0,0 +1,290 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/google/go-react/pkg/llms"
+)
+
+// ErrNoInteraction is returned by a replaying Cassette when no recorded
+// interaction matches the prompt.
+var ErrNoInteraction = errors.New("no recorded interaction matches the prompt")
+
+// Match decides which recorded interaction is replayed for a prompt.
+type Match int
+
+const (
+	// MatchExact replays the first unused interaction with the same prompt.
+	MatchExact Match = iota
+	// MatchWhitespace replays the first unused interaction with the same prompt
+	// once runs of whitespace are collapsed, so reformatting a template doesn't
+	// break the cassette.
+	MatchWhitespace
+	// MatchSequential replays the interactions in the order they were recorded
+	// regardless of the prompt.
+	MatchSequential
+)
+
+// Interaction is a recorded call to an LLM.
+type Interaction struct {
+	Prompt   string          `json:"prompt"`
+	Params   json.RawMessage `json:"params,omitempty"`
+	Response string          `json:"response"`
+	// Usage and FinishReason are the metadata reported by the LLM.
+	Usage        llms.Usage        `json:"usage"`
+	FinishReason llms.FinishReason `json:"finishReason,omitempty"`
+	// Chunks are set when the response was streamed.
+	Chunks []string `json:"chunks,omitempty"`
+	// Err is set when the LLM returned an error.
+	Err string `json:"err,omitempty"`
+	// ErrClass is the class of the error (see llms.ErrorClass), so that the
+	// replayed error wraps the same llms error and is retried the same way.
+	ErrClass string `json:"errClass,omitempty"`
+}
+
+// Cassette records the interactions with an LLM to a file so that they can be
+// replayed later (e.g., to run an agent in CI without calling a real LLM). It
+// is safe for concurrent use.
+type Cassette[TParams any] struct {
+	mu           sync.Mutex
+	path         string
+	llm          llms.LLM[TParams]
+	match        Match
+	interactions []Interaction
+	used         []bool
+}
+
+var (
+	_ llms.StreamLLM[int]   = (*Cassette[int])(nil)
+	_ llms.MetadataLLM[int] = (*Cassette[int])(nil)
+)
+
+// NewRecordingCassette returns a Cassette that calls the LLM and writes each
+// interaction to the file at path. The file is replaced.
+func NewRecordingCassette[TParams any](path string, llm llms.LLM[TParams]) *Cassette[TParams] {
+	return &Cassette[TParams]{
+		path: path,
+		llm:  llm,
+	}
+}
+
+// NewReplayingCassette returns a Cassette that replays the interactions from
+// the file at path. The prompt params are not used for matching.
+func NewReplayingCassette[TParams any](path string, match Match) (*Cassette[TParams], error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %v", err)
+	}
+
+	var f cassetteFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to decode cassette: %v", err)
+	}
+	return &Cassette[TParams]{
+		path:         path,
+		match:        match,
+		interactions: f.Interactions,
+		used:         make([]bool, len(f.Interactions)),
+	}, nil
+}
+
+// Interactions returns the interactions that were recorded or loaded.
+func (c *Cassette[TParams]) Interactions() []Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Interaction(nil), c.interactions...)
+}
+
+// Generate implements llms.LLM.
+func (c *Cassette[TParams]) Generate(ctx context.Context, prompt string, params TParams) (string, error) {
+	result, err := c.GenerateWithMetadata(ctx, prompt, params)
+	if err != nil {
+		return "", err
+	}
+	return result.Text, nil
+}
+
+// GenerateWithMetadata implements llms.MetadataLLM. Only the usage and finish
+// reason are recorded with the text.
+func (c *Cassette[TParams]) GenerateWithMetadata(ctx context.Context, prompt string, params TParams) (llms.Result, error) {
+	if c.llm == nil {
+		i, err := c.replay(prompt)
+		if err != nil {
+			return llms.Result{}, err
+		}
+		if err := i.asError(); err != nil {
+			return llms.Result{}, err
+		}
+		return llms.Result{
+			Text:         i.Response,
+			Usage:        i.Usage,
+			FinishReason: i.FinishReason,
+		}, nil
+	}
+
+	result, err := llms.GenerateWithMetadata(ctx, c.llm, prompt, params)
+	i := Interaction{
+		Prompt:       prompt,
+		Response:     result.Text,
+		Usage:        result.Usage,
+		FinishReason: result.FinishReason,
+	}
+	i.setError(err)
+	if e := c.record(i, params); e != nil {
+		return llms.Result{}, e
+	}
+	return result, err
+}
+
+// GenerateStream implements llms.StreamLLM. When recording, the whole stream
+// is read before it is returned.
+func (c *Cassette[TParams]) GenerateStream(ctx context.Context, prompt string, params TParams) (llms.Stream, error) {
+	if c.llm == nil {
+		i, err := c.replay(prompt)
+		if err != nil {
+			return nil, err
+		}
+		if err := i.asError(); err != nil {
+			return nil, err
+		}
+		if i.Chunks == nil {
+			return llms.NewStaticStream(i.Response), nil
+		}
+		return llms.NewStaticStream(i.Chunks...), nil
+	}
+
+	i := Interaction{Prompt: prompt}
+	s, err := llms.GenerateStream(ctx, c.llm, prompt, params)
+	if err == nil {
+		i.Chunks, err = readChunks(s)
+		i.Response = strings.Join(i.Chunks, "")
+	}
+	i.setError(err)
+	if e := c.record(i, params); e != nil {
+		return nil, e
+	}
+	if err != nil {
+		return nil, err
+	}
+	return llms.NewStaticStream(i.Chunks...), nil
+}
+
+func (c *Cassette[TParams]) replay(prompt string) (Interaction, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for idx, i := range c.interactions {
+		if c.used[idx] {
+			continue
+		}
+		if c.match == MatchSequential ||
+			(c.match == MatchExact && i.Prompt == prompt) ||
+			(c.match == MatchWhitespace && normalizeWhitespace(i.Prompt) == normalizeWhitespace(prompt)) {
+			c.used[idx] = true
+			return i, nil
+		}
+	}
+	return Interaction{}, fmt.Errorf("%w: %q", ErrNoInteraction, prompt)
+}
+
+// record appends the interaction and rewrites the file, so that the cassette
+// is complete even if the test fails part of the way through.
+func (c *Cassette[TParams]) record(i Interaction, params TParams) error {
+	p, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to encode params: %v", err)
+	}
+	i.Params = p
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.interactions = append(c.interactions, i)
+	data, err := json.MarshalIndent(cassetteFile{Interactions: c.interactions}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %v", err)
+	}
+	if err := os.WriteFile(c.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %v", err)
+	}
+	return nil
+}
+
+func (i *Interaction) setError(err error) {
+	if err == nil {
+		return
+	}
+	i.Err = err.Error()
+	i.ErrClass = llms.ErrorClass(err)
+}
+
+func (i Interaction) asError() error {
+	if i.Err == "" {
+		return nil
+	}
+	if err := llms.ClassError(i.ErrClass); err != nil {
+		return replayedError{msg: i.Err, err: err}
+	}
+	return errors.New(i.Err)
+}
+
+// replayedError is a recorded error that wraps the error of its class.
+type replayedError struct {
+	msg string
+	err error
+}
+
+// Error implements error.
+func (e replayedError) Error() string {
+	return e.msg
+}
+
+// Unwrap returns the error of the class.
+func (e replayedError) Unwrap() error {
+	return e.err
+}
+
+func readChunks(s llms.Stream) ([]string, error) {
+	defer s.Close()
+
+	chunks := []string{}
+	for {
+		chunk, err := s.Recv()
+		if errors.Is(err, io.EOF) {
+			return chunks, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, chunk)
+	}
+}
+
+func normalizeWhitespace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+type cassetteFile struct {
+	Interactions []Interaction `json:"interactions"`
+}
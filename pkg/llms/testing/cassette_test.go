@@ -0,0 +1,243 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testing_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-react/pkg/llms"
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+)
+
+func TestCassette(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		match    llmstesting.Match
+		prompts  []string
+		expected []string
+		err      error
+	}{
+		{
+			name:     "exact",
+			match:    llmstesting.MatchExact,
+			prompts:  []string{"other-prompt", "some-prompt"},
+			expected: []string{"other-response", "some-response"},
+		},
+		{
+			name:    "exact mismatch",
+			match:   llmstesting.MatchExact,
+			prompts: []string{"some-prompt "},
+			err:     llmstesting.ErrNoInteraction,
+		},
+		{
+			name:     "whitespace",
+			match:    llmstesting.MatchWhitespace,
+			prompts:  []string{"other-prompt\n", " some-prompt"},
+			expected: []string{"other-response", "some-response"},
+		},
+		{
+			name:     "sequential",
+			match:    llmstesting.MatchSequential,
+			prompts:  []string{"changed-prompt", "changed-prompt"},
+			expected: []string{"some-response", "other-response"},
+		},
+		{
+			name:    "sequential runs out",
+			match:   llmstesting.MatchSequential,
+			prompts: []string{"changed-prompt", "changed-prompt", "changed-prompt"},
+			err:     llmstesting.ErrNoInteraction,
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "cassette.json")
+			fake := &llmstesting.Fake[int]{
+				Outputs: map[string]string{
+					"some-prompt":  "some-response",
+					"other-prompt": "other-response",
+				},
+			}
+
+			recorder := llmstesting.NewRecordingCassette[int](path, fake)
+			for _, prompt := range []string{"some-prompt", "other-prompt"} {
+				if _, err := recorder.Generate(context.Background(), prompt, 1); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			replayer, err := llmstesting.NewReplayingCassette[int](path, tc.match)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var actual []string
+			for _, prompt := range tc.prompts {
+				resp, err := replayer.Generate(context.Background(), prompt, 1)
+				if tc.err != nil && errors.Is(err, tc.err) {
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				actual = append(actual, resp)
+			}
+			if tc.err != nil {
+				t.Fatalf("expected %v", tc.err)
+			}
+			if actual, expected := strings.Join(actual, "|"), strings.Join(tc.expected, "|"); actual != expected {
+				t.Fatalf("expected %q, got %q", expected, actual)
+			}
+		})
+	}
+}
+
+func TestCassette_errors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		err       error
+		expected  error
+		retryable bool
+	}{
+		{
+			name:      "unclassified",
+			err:       errors.New("some-error"),
+			retryable: true,
+		},
+		{
+			name:      "invalid request",
+			err:       &llms.APIError{StatusCode: 400, Message: "some-error", Err: llms.ErrInvalidRequest},
+			expected:  llms.ErrInvalidRequest,
+			retryable: false,
+		},
+		{
+			name:      "rate limited",
+			err:       fmt.Errorf("%w: some-error", llms.ErrRateLimited),
+			expected:  llms.ErrRateLimited,
+			retryable: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "cassette.json")
+			fake := &llmstesting.Fake[int]{Err: tc.err}
+			if _, err := llmstesting.NewRecordingCassette[int](path, fake).Generate(context.Background(), "some-prompt", 1); err == nil {
+				t.Fatal("expected error")
+			}
+
+			replayer, err := llmstesting.NewReplayingCassette[int](path, llmstesting.MatchExact)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = replayer.Generate(context.Background(), "some-prompt", 1)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if actual, expected := err.Error(), tc.err.Error(); actual != expected {
+				t.Fatalf("expected %q, got %q", expected, actual)
+			}
+			if tc.expected != nil && !errors.Is(err, tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, err)
+			}
+			if actual, expected := llms.IsRetryable(err), tc.retryable; actual != expected {
+				t.Fatalf("expected retryable %v, got %v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestCassette_metadata(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	expected := llms.Result{
+		Text:         "some-response",
+		Usage:        llms.Usage{InputTokens: 3, OutputTokens: 5},
+		FinishReason: llms.FinishReasonMaxTokens,
+	}
+	fake := &llmstesting.Fake[int]{Results: map[string]llms.Result{"some-prompt": expected}}
+	if _, err := llmstesting.NewRecordingCassette[int](path, fake).Generate(context.Background(), "some-prompt", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	replayer, err := llmstesting.NewReplayingCassette[int](path, llmstesting.MatchExact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := llms.GenerateWithMetadata[int](context.Background(), replayer, "some-prompt", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, actual)
+	}
+}
+
+func TestCassette_stream(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	fake := &llmstesting.Fake[int]{
+		Chunks: map[string][]string{"some-prompt": {"some-", "response"}},
+	}
+	recorder := llmstesting.NewRecordingCassette[int](path, fake)
+	replayer := func() llms.LLM[int] {
+		r, err := llmstesting.NewReplayingCassette[int](path, llmstesting.MatchExact)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+
+	for _, llm := range []func() llms.LLM[int]{func() llms.LLM[int] { return recorder }, replayer} {
+		s, err := llms.GenerateStream(context.Background(), llm(), "some-prompt", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var chunks []string
+		for {
+			chunk, err := s.Recv()
+			if err != nil {
+				break
+			}
+			chunks = append(chunks, chunk)
+		}
+		if actual, expected := strings.Join(chunks, "|"), "some-|response"; actual != expected {
+			t.Fatalf("expected %q, got %q", expected, actual)
+		}
+	}
+
+	if actual, expected := recorder.Interactions()[0].Response, "some-response"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+}
@@ -16,12 +16,18 @@ package testing
 
 import (
 	"context"
+	"strings"
+	"sync"
 
 	"github.com/google/go-react/pkg/llms"
 )
 
-// Fake implements the llms.LLMS interface for testing.
+// Fake implements the llms.LLMS interface for testing. It is safe for
+// concurrent use as long as its fields are not changed during the calls. Use
+// Script for a sequence of responses.
 type Fake[TParams any] struct {
+	mu sync.Mutex
+
 	Prompts    []string
 	Params     []TParams
 	Outputs    map[string]string
@@ -29,14 +35,47 @@ type Fake[TParams any] struct {
 	Errs       map[string]error
 	GenerateF  func(ctx context.Context, prompt string)
 	AlwaysText string
+	// Chunks are the chunks returned by GenerateStream for the given prompt.
+	// When a prompt is not found, the output from Generate is returned as a
+	// single chunk.
+	Chunks map[string][]string
+	// Results are the results returned by GenerateWithMetadata for the given
+	// prompt. When a prompt is not found, the output from Generate is returned
+	// without any metadata.
+	Results map[string]llms.Result
+	// Candidates are the results returned by GenerateCandidates for the given
+	// prompt. When a prompt is not found, the output from Generate is returned
+	// n times.
+	Candidates map[string][]llms.Result
+	// TokenCounts are the counts returned by CountTokens for the given prompt.
+	// When a prompt is not found, the tokens are estimated with
+	// llms.EstimateTokens.
+	TokenCounts map[string]int
+	// Info is returned by ModelInfo. The model is unknown when it is empty.
+	Info llms.ModelInfo
+	// Functions are the functions given to each GenerateWithFunctions call.
+	Functions [][]llms.FunctionDeclaration
+	// FunctionCalls are the calls returned by GenerateWithFunctions for the
+	// given prompt. When a prompt is not found, the output from Generate is
+	// returned as text.
+	FunctionCalls map[string][]llms.FunctionCall
+	// Parts are the parts given to each GenerateMultimodal call.
+	Parts [][]llms.Part
 }
 
-var _ llms.LLM[int] = (*Fake[int])(nil)
+var (
+	_ llms.StreamLLM[int]          = (*Fake[int])(nil)
+	_ llms.MetadataLLM[int]        = (*Fake[int])(nil)
+	_ llms.CandidatesLLM[int]      = (*Fake[int])(nil)
+	_ llms.TokenCounter[int]       = (*Fake[int])(nil)
+	_ llms.ModelInfoLLM[int]       = (*Fake[int])(nil)
+	_ llms.FunctionCallingLLM[int] = (*Fake[int])(nil)
+	_ llms.MultimodalLLM[int]      = (*Fake[int])(nil)
+)
 
 // Generate implements the llms.LLMS interface.
 func (f *Fake[TParams]) Generate(ctx context.Context, prompt string, params TParams) (string, error) {
-	f.Prompts = append(f.Prompts, prompt)
-	f.Params = append(f.Params, params)
+	f.record(prompt, params)
 	if f.Err != nil {
 		return "", f.Err
 	}
@@ -50,3 +89,109 @@ func (f *Fake[TParams]) Generate(ctx context.Context, prompt string, params TPar
 
 	return f.Outputs[prompt], f.Errs[prompt]
 }
+
+// GenerateStream implements the llms.StreamLLM interface.
+func (f *Fake[TParams]) GenerateStream(ctx context.Context, prompt string, params TParams) (llms.Stream, error) {
+	if chunks, ok := f.Chunks[prompt]; ok {
+		f.record(prompt, params)
+		if f.Err != nil {
+			return nil, f.Err
+		}
+		return llms.NewStaticStream(chunks...), nil
+	}
+
+	resp, err := f.Generate(ctx, prompt, params)
+	if err != nil {
+		return nil, err
+	}
+	return llms.NewStaticStream(resp), nil
+}
+
+// GenerateWithMetadata implements the llms.MetadataLLM interface.
+func (f *Fake[TParams]) GenerateWithMetadata(ctx context.Context, prompt string, params TParams) (llms.Result, error) {
+	if result, ok := f.Results[prompt]; ok {
+		f.record(prompt, params)
+		if f.Err != nil {
+			return llms.Result{}, f.Err
+		}
+		return result, nil
+	}
+
+	resp, err := f.Generate(ctx, prompt, params)
+	if err != nil {
+		return llms.Result{}, err
+	}
+	return llms.Result{Text: resp}, nil
+}
+
+// GenerateCandidates implements the llms.CandidatesLLM interface.
+func (f *Fake[TParams]) GenerateCandidates(ctx context.Context, prompt string, params TParams, n int) ([]llms.Result, error) {
+	if results, ok := f.Candidates[prompt]; ok {
+		f.record(prompt, params)
+		if f.Err != nil {
+			return nil, f.Err
+		}
+		return results, nil
+	}
+
+	var results []llms.Result
+	for i := 0; i < n; i++ {
+		result, err := f.GenerateWithMetadata(ctx, prompt, params)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// CountTokens implements the llms.TokenCounter interface.
+func (f *Fake[TParams]) CountTokens(ctx context.Context, prompt string, params TParams) (int, error) {
+	if n, ok := f.TokenCounts[prompt]; ok {
+		return n, nil
+	}
+	return llms.EstimateTokens(prompt), nil
+}
+
+// ModelInfo implements the llms.ModelInfoLLM interface.
+func (f *Fake[TParams]) ModelInfo(params TParams) (llms.ModelInfo, bool) {
+	return f.Info, f.Info != llms.ModelInfo{}
+}
+
+// GenerateWithFunctions implements the llms.FunctionCallingLLM interface.
+func (f *Fake[TParams]) GenerateWithFunctions(ctx context.Context, prompt string, functions []llms.FunctionDeclaration, params TParams) (llms.Result, error) {
+	f.mu.Lock()
+	f.Functions = append(f.Functions, functions)
+	f.mu.Unlock()
+
+	if calls, ok := f.FunctionCalls[prompt]; ok {
+		f.record(prompt, params)
+		if f.Err != nil {
+			return llms.Result{}, f.Err
+		}
+		return llms.Result{FunctionCalls: calls}, nil
+	}
+	return f.GenerateWithMetadata(ctx, prompt, params)
+}
+
+// GenerateMultimodal implements the llms.MultimodalLLM interface. The parts
+// are recorded and the text parts are joined into the prompt that is given to
+// GenerateWithMetadata.
+func (f *Fake[TParams]) GenerateMultimodal(ctx context.Context, parts []llms.Part, params TParams) (llms.Result, error) {
+	f.mu.Lock()
+	f.Parts = append(f.Parts, parts)
+	f.mu.Unlock()
+
+	var prompt strings.Builder
+	for _, p := range parts {
+		prompt.WriteString(p.Text)
+	}
+	return f.GenerateWithMetadata(ctx, prompt.String(), params)
+}
+
+func (f *Fake[TParams]) record(prompt string, params TParams) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Prompts = append(f.Prompts, prompt)
+	f.Params = append(f.Params, params)
+}
@@ -0,0 +1,55 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testing
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/go-react/pkg/llms"
+)
+
+// FakeChat implements the llms.ChatLLM interface for testing. It is safe for
+// concurrent use as long as its fields are not changed during the calls.
+type FakeChat[TParams any] struct {
+	mu sync.Mutex
+
+	Messages   [][]llms.Message
+	Params     []TParams
+	Err        error
+	ChatF      func(ctx context.Context, messages []llms.Message) (llms.Message, error)
+	AlwaysText string
+}
+
+var _ llms.ChatLLM[int] = (*FakeChat[int])(nil)
+
+// Chat implements the llms.ChatLLM interface.
+func (f *FakeChat[TParams]) Chat(ctx context.Context, messages []llms.Message, params TParams) (llms.Message, error) {
+	f.mu.Lock()
+	f.Messages = append(f.Messages, messages)
+	f.Params = append(f.Params, params)
+	f.mu.Unlock()
+
+	if f.Err != nil {
+		return llms.Message{}, f.Err
+	}
+	if f.AlwaysText != "" {
+		return llms.Message{Role: llms.RoleAssistant, Content: f.AlwaysText}, nil
+	}
+	if f.ChatF != nil {
+		return f.ChatF(ctx, messages)
+	}
+	return llms.Message{Role: llms.RoleAssistant}, nil
+}
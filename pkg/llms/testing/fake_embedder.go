@@ -0,0 +1,95 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testing
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"sync"
+
+	"github.com/google/go-react/pkg/llms"
+)
+
+// FakeEmbedder implements the llms.Embedder interface for testing. Its
+// embeddings are derived from a hash of the text, so the same text always
+// gets the same embedding while different texts get unrelated ones. It is
+// safe for concurrent use as long as its fields are not changed during the
+// calls.
+type FakeEmbedder[TParams any] struct {
+	mu sync.Mutex
+
+	Texts  [][]string
+	Tasks  []llms.TaskType
+	Params []TParams
+	Err    error
+	// Embeddings are the embeddings returned for the given text. When a text
+	// is not found, an embedding is derived from its hash.
+	Embeddings map[string]llms.Embedding
+	// Dimensions is the number of dimensions of the derived embeddings. It
+	// defaults to 8.
+	Dimensions int
+}
+
+var _ llms.Embedder[int] = (*FakeEmbedder[int])(nil)
+
+// Embed implements the llms.Embedder interface.
+func (f *FakeEmbedder[TParams]) Embed(ctx context.Context, texts []string, task llms.TaskType, params TParams) ([]llms.Embedding, error) {
+	f.mu.Lock()
+	f.Texts = append(f.Texts, texts)
+	f.Tasks = append(f.Tasks, task)
+	f.Params = append(f.Params, params)
+	f.mu.Unlock()
+
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	var embeddings []llms.Embedding
+	for _, text := range texts {
+		if e, ok := f.Embeddings[text]; ok {
+			embeddings = append(embeddings, e)
+			continue
+		}
+		embeddings = append(embeddings, f.derive(text))
+	}
+	return embeddings, nil
+}
+
+// derive returns a unit length embedding from the hash of the text.
+func (f *FakeEmbedder[TParams]) derive(text string) llms.Embedding {
+	dimensions := f.Dimensions
+	if dimensions == 0 {
+		dimensions = 8
+	}
+
+	e := make(llms.Embedding, dimensions)
+	var norm float64
+	for i := range e {
+		// Hash the index along with the text so that any number of dimensions
+		// can be derived.
+		h := sha256.New()
+		binary.Write(h, binary.BigEndian, uint32(i))
+		h.Write([]byte(text))
+		v := float64(binary.BigEndian.Uint32(h.Sum(nil)))/math.MaxUint32*2 - 1
+		e[i] = float32(v)
+		norm += v * v
+	}
+	for i := range e {
+		e[i] = float32(float64(e[i]) / math.Sqrt(norm))
+	}
+	return e
+}
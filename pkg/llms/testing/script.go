@@ -0,0 +1,221 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/google/go-react/pkg/llms"
+)
+
+// ErrUnexpectedCall is returned by a Script when no step matches the prompt.
+var ErrUnexpectedCall = errors.New("unexpected call to the scripted LLM")
+
+// TestingT is the part of testing.T that is used by Script.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+	Cleanup(func())
+}
+
+// Call is a call made to a Script.
+type Call[TParams any] struct {
+	Prompt string
+	Params TParams
+}
+
+// Script is a fake LLM that responds with a scripted sequence of steps. Each
+// call is answered by the first step, in the order they were added, that
+// matches the prompt and has not been used up. The test fails if a call
+// matches no step, or if a step was not used up by the end of the test.
+//
+// It is safe for concurrent use once the steps are added, so it can be used
+// with predictors that call the LLM from multiple goroutines.
+type Script[TParams any] struct {
+	t TestingT
+
+	mu       sync.Mutex
+	steps    []*Step
+	calls    []Call[TParams]
+	callErrs map[int]error
+}
+
+var (
+	_ llms.StreamLLM[int]   = (*Script[int])(nil)
+	_ llms.MetadataLLM[int] = (*Script[int])(nil)
+)
+
+// NewScript returns a Script without any steps. AssertConsumed is called when
+// the test finishes.
+func NewScript[TParams any](t TestingT) *Script[TParams] {
+	s := &Script[TParams]{
+		t:        t,
+		callErrs: map[int]error{},
+	}
+	t.Cleanup(s.AssertConsumed)
+	return s
+}
+
+// Step is a scripted response. It answers a single call unless Times or
+// AnyTimes is used.
+type Step struct {
+	desc   string
+	match  func(prompt string) bool
+	result llms.Result
+	err    error
+	times  int
+	calls  int
+}
+
+// Expect adds a step that matches any prompt.
+func (s *Script[TParams]) Expect() *Step {
+	return s.add("any prompt", func(string) bool { return true })
+}
+
+// ExpectContains adds a step that matches the prompts containing substr.
+func (s *Script[TParams]) ExpectContains(substr string) *Step {
+	return s.add(fmt.Sprintf("prompt containing %q", substr), func(prompt string) bool {
+		return strings.Contains(prompt, substr)
+	})
+}
+
+// ExpectRegexp adds a step that matches the prompts matching the pattern. It
+// panics if the pattern doesn't compile.
+func (s *Script[TParams]) ExpectRegexp(pattern string) *Step {
+	re := regexp.MustCompile(pattern)
+	return s.add(fmt.Sprintf("prompt matching %q", pattern), re.MatchString)
+}
+
+func (s *Script[TParams]) add(desc string, match func(string) bool) *Step {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := &Step{desc: desc, match: match, times: 1}
+	s.steps = append(s.steps, st)
+	return st
+}
+
+// Respond makes the step respond with the text.
+func (st *Step) Respond(text string) *Step {
+	st.result = llms.Result{Text: text}
+	return st
+}
+
+// RespondResult makes the step respond with the result (e.g., to set the
+// usage or finish reason).
+func (st *Step) RespondResult(result llms.Result) *Step {
+	st.result = result
+	return st
+}
+
+// Fail makes the step fail with err.
+func (st *Step) Fail(err error) *Step {
+	st.err = err
+	return st
+}
+
+// Times makes the step answer n calls.
+func (st *Step) Times(n int) *Step {
+	st.times = n
+	return st
+}
+
+// AnyTimes makes the step answer any number of calls, including none. It is
+// never used up, so the steps after it that match the same prompts are not
+// reached.
+func (st *Step) AnyTimes() *Step {
+	st.times = -1
+	return st
+}
+
+func (st *Step) usedUp() bool {
+	return st.times >= 0 && st.calls >= st.times
+}
+
+// FailCall makes the nth call (starting at 1) fail with err, whatever the
+// prompt. The call doesn't use up a step.
+func (s *Script[TParams]) FailCall(n int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.callErrs[n] = err
+}
+
+// Calls returns the calls made so far.
+func (s *Script[TParams]) Calls() []Call[TParams] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call[TParams](nil), s.calls...)
+}
+
+// AssertConsumed fails the test if a step has not been used up.
+func (s *Script[TParams]) AssertConsumed() {
+	s.t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, st := range s.steps {
+		if st.times >= 0 && st.calls < st.times {
+			s.t.Errorf("step %d (%s) was called %d of %d times", i+1, st.desc, st.calls, st.times)
+		}
+	}
+}
+
+// Generate implements llms.LLM.
+func (s *Script[TParams]) Generate(ctx context.Context, prompt string, params TParams) (string, error) {
+	result, err := s.GenerateWithMetadata(ctx, prompt, params)
+	if err != nil {
+		return "", err
+	}
+	return result.Text, nil
+}
+
+// GenerateWithMetadata implements llms.MetadataLLM.
+func (s *Script[TParams]) GenerateWithMetadata(ctx context.Context, prompt string, params TParams) (llms.Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls = append(s.calls, Call[TParams]{Prompt: prompt, Params: params})
+	if err, ok := s.callErrs[len(s.calls)]; ok {
+		return llms.Result{}, err
+	}
+
+	for _, st := range s.steps {
+		if st.usedUp() || !st.match(prompt) {
+			continue
+		}
+		st.calls++
+		if st.err != nil {
+			return llms.Result{}, st.err
+		}
+		return st.result, nil
+	}
+
+	s.t.Helper()
+	s.t.Errorf("call %d: no step matches the prompt: %q", len(s.calls), prompt)
+	return llms.Result{}, fmt.Errorf("%w: %q", ErrUnexpectedCall, prompt)
+}
+
+// GenerateStream implements llms.StreamLLM. The text is returned as a single
+// chunk.
+func (s *Script[TParams]) GenerateStream(ctx context.Context, prompt string, params TParams) (llms.Stream, error) {
+	result, err := s.GenerateWithMetadata(ctx, prompt, params)
+	if err != nil {
+		return nil, err
+	}
+	return llms.NewStaticStream(result.Text), nil
+}
@@ -0,0 +1,202 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testing_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/google/go-react/pkg/llms"
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+)
+
+// recordingT records the errors instead of failing the test.
+type recordingT struct {
+	mu       sync.Mutex
+	errs     []string
+	cleanups []func()
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...any) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.errs = append(t.errs, fmt.Sprintf(format, args...))
+}
+
+func (t *recordingT) Cleanup(f func()) {
+	t.cleanups = append(t.cleanups, f)
+}
+
+func (t *recordingT) finish() {
+	for _, f := range t.cleanups {
+		f()
+	}
+}
+
+func TestScript(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name         string
+		setup        func(*llmstesting.Script[int])
+		prompts      []string
+		expected     []string
+		expectedErrs []error
+		// failures is the number of test failures reported by the script.
+		failures int
+	}{
+		{
+			name: "sequence",
+			setup: func(s *llmstesting.Script[int]) {
+				s.Expect().Respond("first")
+				s.Expect().Respond("second")
+			},
+			prompts:      []string{"some-prompt", "some-prompt"},
+			expected:     []string{"first", "second"},
+			expectedErrs: []error{nil, nil},
+		},
+		{
+			name: "pattern matched",
+			setup: func(s *llmstesting.Script[int]) {
+				s.ExpectContains("weather").Respond("sunny")
+				s.ExpectRegexp(`^Question: \d+$`).Times(2).Respond("42")
+			},
+			prompts:      []string{"Question: 1", "what's the weather?", "Question: 2"},
+			expected:     []string{"42", "sunny", "42"},
+			expectedErrs: []error{nil, nil, nil},
+		},
+		{
+			name: "any times",
+			setup: func(s *llmstesting.Script[int]) {
+				s.ExpectContains("retry").AnyTimes().Respond("again")
+				s.ExpectContains("unused").AnyTimes().Respond("never")
+			},
+			prompts:      []string{"retry", "retry", "retry"},
+			expected:     []string{"again", "again", "again"},
+			expectedErrs: []error{nil, nil, nil},
+		},
+		{
+			name: "step fails",
+			setup: func(s *llmstesting.Script[int]) {
+				s.Expect().Fail(llms.ErrSafetyBlocked)
+				s.Expect().Respond("some-output")
+			},
+			prompts:      []string{"some-prompt", "some-prompt"},
+			expected:     []string{"", "some-output"},
+			expectedErrs: []error{llms.ErrSafetyBlocked, nil},
+		},
+		{
+			name: "nth call fails",
+			setup: func(s *llmstesting.Script[int]) {
+				s.FailCall(2, llms.ErrRateLimited)
+				s.Expect().Times(2).Respond("some-output")
+			},
+			prompts:      []string{"some-prompt", "some-prompt", "some-prompt"},
+			expected:     []string{"some-output", "", "some-output"},
+			expectedErrs: []error{nil, llms.ErrRateLimited, nil},
+		},
+		{
+			name: "unexpected call",
+			setup: func(s *llmstesting.Script[int]) {
+				s.ExpectContains("weather").Respond("sunny")
+			},
+			prompts:      []string{"weather", "weather"},
+			expected:     []string{"sunny", ""},
+			expectedErrs: []error{nil, llmstesting.ErrUnexpectedCall},
+			failures:     1,
+		},
+		{
+			name: "not consumed",
+			setup: func(s *llmstesting.Script[int]) {
+				s.Expect().Respond("first")
+				s.Expect().Times(2).Respond("second")
+			},
+			prompts:      []string{"some-prompt", "some-prompt"},
+			expected:     []string{"first", "second"},
+			expectedErrs: []error{nil, nil},
+			failures:     1,
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			rt := &recordingT{}
+			s := llmstesting.NewScript[int](rt)
+			tc.setup(s)
+
+			for i, prompt := range tc.prompts {
+				output, err := s.Generate(context.Background(), prompt, i)
+				if actual, expected := err, tc.expectedErrs[i]; !errors.Is(actual, expected) {
+					t.Fatalf("call %d: expected %v, got %v", i+1, expected, actual)
+				}
+				if actual, expected := output, tc.expected[i]; actual != expected {
+					t.Fatalf("call %d: expected %q, got %q", i+1, expected, actual)
+				}
+			}
+			rt.finish()
+
+			if actual, expected := len(rt.errs), tc.failures; actual != expected {
+				t.Fatalf("expected %d failures, got %d: %q", expected, actual, rt.errs)
+			}
+			if actual, expected := len(s.Calls()), len(tc.prompts); actual != expected {
+				t.Fatalf("expected %d, got %d", expected, actual)
+			}
+		})
+	}
+}
+
+func TestScript_concurrent(t *testing.T) {
+	t.Parallel()
+
+	s := llmstesting.NewScript[int](t)
+	s.ExpectContains("even").Times(50).Respond("some-even-output")
+	s.ExpectContains("odd").Times(50).Respond("some-odd-output")
+	fake := &llmstesting.Fake[int]{AlwaysText: "some-output"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			prompt := "odd"
+			if i%2 == 0 {
+				prompt = "even"
+			}
+			if _, err := s.Generate(context.Background(), prompt, i); err != nil {
+				t.Error(err)
+			}
+			if _, err := fake.Generate(context.Background(), prompt, i); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if actual, expected := len(s.Calls()), 100; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+	if actual, expected := len(fake.Prompts), 100; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms
+
+import (
+	"context"
+	"fmt"
+)
+
+// EstimateTokens estimates the number of tokens in the text using the rule of
+// thumb that a token is about four characters.
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// TokenCounter is an LLM that can count the tokens in a prompt the way its
+// model does.
+type TokenCounter[TParams any] interface {
+	LLM[TParams]
+	// CountTokens returns the number of tokens in the prompt for the model
+	// selected by the params.
+	CountTokens(ctx context.Context, prompt string, params TParams) (int, error)
+}
+
+// CountTokens counts the tokens in the prompt with the LLM if it implements
+// TokenCounter. Otherwise, it falls back to EstimateTokens.
+func CountTokens[TParams any](ctx context.Context, llm LLM[TParams], prompt string, params TParams) (int, error) {
+	if c, ok := llm.(TokenCounter[TParams]); ok {
+		return c.CountTokens(ctx, prompt, params)
+	}
+	return EstimateTokens(prompt), nil
+}
+
+// ModelInfo describes the limits of a model.
+type ModelInfo struct {
+	// ContextWindow is the max number of tokens in the prompt.
+	ContextWindow int
+	// MaxOutputTokens is the max number of tokens the model can generate.
+	MaxOutputTokens int
+}
+
+// ModelInfoLLM is an LLM that knows the limits of its models.
+type ModelInfoLLM[TParams any] interface {
+	LLM[TParams]
+	// ModelInfo returns the limits of the model selected by the params. It
+	// returns false when the model is not known.
+	ModelInfo(params TParams) (ModelInfo, bool)
+}
+
+// LookupModelInfo returns the limits of the model selected by the params if
+// the LLM implements ModelInfoLLM and knows the model.
+func LookupModelInfo[TParams any](llm LLM[TParams], params TParams) (ModelInfo, bool) {
+	if m, ok := llm.(ModelInfoLLM[TParams]); ok {
+		return m.ModelInfo(params)
+	}
+	return ModelInfo{}, false
+}
+
+// CheckContextWindow returns an error that wraps ErrContextLength if the
+// prompt doesn't fit in the model's context window. It returns nil when the
+// LLM doesn't know the model's limits. It can be used to trim a prompt (e.g.,
+// the history of an agent) before calling the LLM.
+func CheckContextWindow[TParams any](ctx context.Context, llm LLM[TParams], prompt string, params TParams) error {
+	info, ok := LookupModelInfo(llm, params)
+	if !ok || info.ContextWindow == 0 {
+		return nil
+	}
+
+	tokens, err := CountTokens(ctx, llm, prompt, params)
+	if err != nil {
+		return fmt.Errorf("failed to count tokens: %w", err)
+	}
+	if tokens > info.ContextWindow {
+		return fmt.Errorf("%w: prompt has %d tokens but the context window is %d", ErrContextLength, tokens, info.ContextWindow)
+	}
+	return nil
+}
+
+// NewContextGuard returns an LLM that checks the prompt with
+// CheckContextWindow before calling the given LLM, so that a prompt that is
+// too long fails with ErrContextLength without being sent. It should wrap the
+// backend directly so that it can use the backend's TokenCounter and
+// ModelInfoLLM.
+func NewContextGuard[TParams any](llm LLM[TParams]) LLM[TParams] {
+	return contextGuard[TParams]{llm: llm}
+}
+
+type contextGuard[TParams any] struct {
+	llm LLM[TParams]
+}
+
+// Generate implements LLM.
+func (g contextGuard[TParams]) Generate(ctx context.Context, prompt string, params TParams) (string, error) {
+	if err := CheckContextWindow(ctx, g.llm, prompt, params); err != nil {
+		return "", err
+	}
+	return g.llm.Generate(ctx, prompt, params)
+}
+
+// GenerateWithMetadata implements MetadataLLM.
+func (g contextGuard[TParams]) GenerateWithMetadata(ctx context.Context, prompt string, params TParams) (Result, error) {
+	if err := CheckContextWindow(ctx, g.llm, prompt, params); err != nil {
+		return Result{}, err
+	}
+	return GenerateWithMetadata(ctx, g.llm, prompt, params)
+}
+
+// GenerateStream implements StreamLLM.
+func (g contextGuard[TParams]) GenerateStream(ctx context.Context, prompt string, params TParams) (Stream, error) {
+	if err := CheckContextWindow(ctx, g.llm, prompt, params); err != nil {
+		return nil, err
+	}
+	return GenerateStream(ctx, g.llm, prompt, params)
+}
+
+// CountTokens implements TokenCounter.
+func (g contextGuard[TParams]) CountTokens(ctx context.Context, prompt string, params TParams) (int, error) {
+	return CountTokens(ctx, g.llm, prompt, params)
+}
+
+// ModelInfo implements ModelInfoLLM.
+func (g contextGuard[TParams]) ModelInfo(params TParams) (ModelInfo, bool) {
+	return LookupModelInfo(g.llm, params)
+}
@@ -0,0 +1,102 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llms_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-react/pkg/llms"
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+)
+
+func TestCountTokens(t *testing.T) {
+	t.Parallel()
+
+	fake := &llmstesting.Fake[int]{TokenCounts: map[string]int{"some-prompt": 3}}
+	n, err := llms.CountTokens[int](context.Background(), fake, "some-prompt", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := n, 3; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+
+	// LLMs that can't count tokens fall back to an estimate.
+	n, err = llms.CountTokens[int](context.Background(), echo{}, "some-prompt", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := n, 3; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+}
+
+func TestContextGuard(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		info     llms.ModelInfo
+		tokens   int
+		expected error
+	}{
+		{
+			name:   "fits",
+			info:   llms.ModelInfo{ContextWindow: 10},
+			tokens: 10,
+		},
+		{
+			name:     "too long",
+			info:     llms.ModelInfo{ContextWindow: 10},
+			tokens:   11,
+			expected: llms.ErrContextLength,
+		},
+		{
+			name:   "unknown model",
+			tokens: 11,
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := &llmstesting.Fake[int]{
+				AlwaysText:  "some-response",
+				TokenCounts: map[string]int{"some-prompt": tc.tokens},
+				Info:        tc.info,
+			}
+			llm := llms.NewContextGuard[int](fake)
+
+			_, err := llm.Generate(context.Background(), "some-prompt", 1)
+			if actual, expected := errors.Is(err, tc.expected), true; actual != expected {
+				t.Fatalf("expected %v, got %v: %v", expected, actual, err)
+			}
+
+			// The prompt is only sent when it fits.
+			expectedPrompts := 1
+			if tc.expected != nil {
+				expectedPrompts = 0
+			}
+			if actual, expected := len(fake.Prompts), expectedPrompts; actual != expected {
+				t.Fatalf("expected %d, got %d", expected, actual)
+			}
+		})
+	}
+}
@@ -18,132 +18,434 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"net/http"
 	"strings"
 
 	"github.com/google/go-react/pkg/llms"
-	oauth2 "golang.org/x/oauth2/google"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
 )
 
-// Params are the parameters for a request.
+// Params are the parameters for a request. The model is used to pick which
+// API is used: Gemini models (e.g., gemini-1.0-pro) use the generateContent
+// API while the PaLM models (e.g., text-bison, code-bison and chat-bison) use
+// the predict API.
 type Params struct {
 	Model       string
 	MaxTokens   int
 	Temperature float64
 	TopK        int
 	TopP        float64
+	// StopSequences stop the generation when one of them is generated.
+	StopSequences []string
+	// CandidateCount and Seed are only sent when they are not zero.
+	CandidateCount int
+	Seed           int
+
+	// SystemInstruction is sent as the system instruction. It is only
+	// supported by Gemini models.
+	SystemInstruction string
+	// SafetySettings configure how content is blocked. They are only supported
+	// by Gemini models.
+	SafetySettings []SafetySetting
+	// FunctionCallingMode is how functions are called when they are given to
+	// GenerateWithFunctions: AUTO (the default), ANY to force a call or NONE.
+	// It is only supported by Gemini models.
+	FunctionCallingMode string
+	// ResponseMIMEType is the MIME type of the response (e.g.,
+	// application/json to constrain it to valid JSON). It is only supported by
+	// Gemini models.
+	ResponseMIMEType string
+}
+
+// FromGenerationConfig converts the common generation params into Params.
+// JSON mode is only supported by Gemini models.
+func FromGenerationConfig(c llms.GenerationConfig) Params {
+	p := Params{
+		Model:         c.Model,
+		MaxTokens:     c.MaxTokens,
+		Temperature:   c.Temperature,
+		TopK:          c.TopK,
+		TopP:          c.TopP,
+		StopSequences: c.StopSequences,
+		Seed:          c.Seed,
+	}
+	if c.JSON {
+		p.ResponseMIMEType = "application/json"
+	}
+	return p
+}
+
+// SafetySetting sets the threshold at which content is blocked for a harm
+// category (e.g., HARM_CATEGORY_HATE_SPEECH and BLOCK_ONLY_HIGH).
+type SafetySetting struct {
+	Category  string `json:"category"`
+	Threshold string `json:"threshold"`
+}
+
+// DefaultLocation is the location requests are sent to by default.
+const DefaultLocation = "us-central1"
+
+// Option is an option for the constructors.
+type Option func(*client)
+
+// WithLocation sets the location (e.g., europe-west4) the requests are sent
+// to. It defaults to DefaultLocation.
+func WithLocation(location string) Option {
+	return func(c *client) {
+		c.location = location
+	}
+}
+
+// WithHTTPClient sets the http.Client used to send requests (e.g., to send
+// them through a proxy). It defaults to http.DefaultClient, which is also used
+// when the client is nil. New still
+// authenticates the requests using the client's transport.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *client) {
+		c.client = httpClient
+	}
 }
 
-// New returns a new Google Palm LLM.
-func New(ctx context.Context, apiEndpoint, projectID string) (llms.LLM[Params], error) {
-	c, err := oauth2.DefaultClient(ctx, "https://www.googleapis.com/auth/cloud-platform")
+// WithPlainHTTP sends the requests over plain HTTP instead of HTTPS. It is
+// meant for emulators (see the vertextest package) and should not be used
+// with the real API, as the access token would be sent in the clear.
+func WithPlainHTTP() Option {
+	return func(c *client) {
+		c.scheme = "http"
+	}
+}
+
+// WithHeader sets an extra header that is sent with each request.
+func WithHeader(key, value string) Option {
+	return func(c *client) {
+		c.headers.Set(key, value)
+	}
+}
+
+// New returns a new Google Vertex AI LLM that uses the application default
+// credentials. If the apiEndpoint is empty, the location's endpoint is used.
+func New(ctx context.Context, apiEndpoint, projectID string, opts ...Option) (llms.LLM[Params], error) {
+	c := newClient("", apiEndpoint, projectID, opts)
+
+	ts, err := google.DefaultTokenSource(ctx, "https://www.googleapis.com/auth/cloud-platform")
 	if err != nil {
 		return nil, err
 	}
+	authClient := *c.client
+	authClient.Transport = &oauth2.Transport{
+		Source: ts,
+		Base:   c.client.Transport,
+	}
+	c.client = &authClient
 
-	return client{
-		projectID:   projectID,
-		apiEndpoint: apiEndpoint,
-		client:      c,
-	}, nil
+	return c, nil
+}
+
+// NewWithKey returns a new Google Vertex AI LLM that uses the given access
+// token. If the apiEndpoint is empty, the location's endpoint is used.
+func NewWithKey(key, apiEndpoint, projectID string, opts ...Option) llms.LLM[Params] {
+	return newClient(key, apiEndpoint, projectID, opts)
+}
+
+// NewChat returns a new Google Vertex AI chat LLM (e.g., chat-bison or
+// gemini-1.0-pro).
+func NewChat(ctx context.Context, apiEndpoint, projectID string, opts ...Option) (llms.ChatLLM[Params], error) {
+	llm, err := New(ctx, apiEndpoint, projectID, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return llm.(client), nil
+}
+
+// NewChatWithKey returns a new Google Vertex AI chat LLM (e.g., chat-bison or
+// gemini-1.0-pro).
+func NewChatWithKey(key, apiEndpoint, projectID string, opts ...Option) llms.ChatLLM[Params] {
+	return newClient(key, apiEndpoint, projectID, opts)
 }
 
-// NewWithKey returns a new Google Palm LLM.
-func NewWithKey(key, apiEndpoint, projectID string) llms.LLM[Params] {
-	return client{
+func newClient(key, apiEndpoint, projectID string, opts []Option) client {
+	c := client{
 		key:         key,
 		projectID:   projectID,
 		apiEndpoint: apiEndpoint,
+		location:    DefaultLocation,
+		scheme:      "https",
 		client:      http.DefaultClient,
+		headers:     http.Header{},
 	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.client == nil {
+		c.client = http.DefaultClient
+	}
+	if c.apiEndpoint == "" {
+		c.apiEndpoint = c.location + "-aiplatform.googleapis.com"
+	}
+	return c
 }
 
 type client struct {
 	key         string
 	projectID   string
 	apiEndpoint string
+	location    string
+	scheme      string
 	client      *http.Client
+	headers     http.Header
+}
+
+// api is the API that is used for a model.
+type api int
+
+const (
+	// apiText is the predict API for text models, where the prompt is sent as
+	// the content.
+	apiText api = iota
+	// apiCode is the predict API for code models, where the prompt is sent as
+	// the prefix.
+	apiCode
+	// apiChat is the predict API for chat models.
+	apiChat
+	// apiGemini is the generateContent API.
+	apiGemini
+)
+
+// apiFor returns the API that is used for the given model.
+func apiFor(model string) (api, error) {
+	name := strings.Split(model, "@")[0]
+	switch {
+	case strings.HasPrefix(name, "gemini-"):
+		return apiGemini, nil
+	case strings.HasPrefix(name, "chat-"), strings.HasPrefix(name, "codechat-"):
+		return apiChat, nil
+	case strings.HasPrefix(name, "code-"):
+		return apiCode, nil
+	case strings.HasPrefix(name, "text-"):
+		return apiText, nil
+	default:
+		return 0, fmt.Errorf("unsupported model %q", model)
+	}
 }
 
 // Generate implements llms.LLM.
 func (c client) Generate(ctx context.Context, prompt string, params Params) (string, error) {
-	// Check to see if the params are empty, if so, set some defaults.
-	if params == (Params{}) {
-		params.Model = "text-bison@001"
-		params.MaxTokens = 64
-		params.Temperature = 0.2
-		params.TopK = 40
-		params.TopP = 0.8
+	result, err := c.GenerateWithMetadata(ctx, prompt, params)
+	if err != nil {
+		return "", err
+	}
+	return result.Text, nil
+}
+
+// GenerateWithMetadata implements llms.MetadataLLM.
+func (c client) GenerateWithMetadata(ctx context.Context, prompt string, params Params) (llms.Result, error) {
+	results, err := c.generate(ctx, prompt, withDefaults(params, "text-bison@001", 64))
+	if err != nil {
+		return llms.Result{}, err
+	}
+	return results[0], nil
+}
+
+// GenerateCandidates implements llms.CandidatesLLM. The n overrides the
+// CandidateCount in the params. The token usage is for the whole request, so
+// it is only set on the first result.
+func (c client) GenerateCandidates(ctx context.Context, prompt string, params Params, n int) ([]llms.Result, error) {
+	params = withDefaults(params, "text-bison@001", 64)
+	params.CandidateCount = n
+	return c.generate(ctx, prompt, params)
+}
+
+// GenerateWithFunctions implements llms.FunctionCallingLLM. It is only
+// supported by Gemini models.
+func (c client) GenerateWithFunctions(ctx context.Context, prompt string, functions []llms.FunctionDeclaration, params Params) (llms.Result, error) {
+	params = withDefaults(params, "text-bison@001", 64)
+	a, err := apiFor(params.Model)
+	if err != nil {
+		return llms.Result{}, err
+	}
+	if a != apiGemini {
+		return llms.Result{}, fmt.Errorf("%w: model %q", llms.ErrFunctionCallingUnsupported, params.Model)
+	}
+
+	results, err := c.generateContent(ctx, nil, []content{userContent(prompt)}, functions, params)
+	if err != nil {
+		return llms.Result{}, err
+	}
+	return results[0], nil
+}
+
+// GenerateMultimodal implements llms.MultimodalLLM. Images and other files are
+// only supported by Gemini models, so the other models only take text parts.
+func (c client) GenerateMultimodal(ctx context.Context, parts []llms.Part, params Params) (llms.Result, error) {
+	params = withDefaults(params, "text-bison@001", 64)
+	a, err := apiFor(params.Model)
+	if err != nil {
+		return llms.Result{}, err
+	}
+
+	var results []llms.Result
+	if a == apiGemini {
+		results, err = c.generateContent(ctx, nil, []content{multimodalContent(parts)}, nil, params)
+	} else {
+		prompt, ok := llms.JoinTextParts(parts)
+		if !ok {
+			return llms.Result{}, fmt.Errorf("%w: model %q", llms.ErrMultimodalUnsupported, params.Model)
+		}
+		results, err = c.generate(ctx, prompt, params)
+	}
+	if err != nil {
+		return llms.Result{}, err
+	}
+	return results[0], nil
+}
+
+// generate sends the prompt to the API used by the model. At least one result
+// is returned if there is no error.
+func (c client) generate(ctx context.Context, prompt string, params Params) ([]llms.Result, error) {
+	a, err := apiFor(params.Model)
+	if err != nil {
+		return nil, err
 	}
 
-	var prefix string
-	switch strings.Split(params.Model, "@")[0] {
-	case "text-bison":
-		prefix = "content"
-	case "code-bison":
-		prefix = "prefix"
+	switch a {
+	case apiGemini:
+		return c.generateContent(ctx, nil, []content{userContent(prompt)}, nil, params)
+	case apiChat:
+		return c.chatPredict(ctx, []llms.Message{{Role: llms.RoleUser, Content: prompt}}, params)
 	default:
-		prefix = "content"
-	}
-
-	req, err := http.NewRequest(
-		http.MethodPost,
-		fmt.Sprintf(
-			"https://%s/v1/projects/%s/locations/us-central1/publishers/google/models/%s:predict",
-			c.apiEndpoint,
-			c.projectID,
-			params.Model,
-		),
-		strings.NewReader(fmt.Sprintf(`
-{
-  "instances": [
-    { %q: %q }
-  ],
-  "parameters": {
-	  "temperature": %f,
-    "maxOutputTokens": %d,
-		"topK": %d,
-		"topP": %f
-  }
-}`, prefix, prompt, params.Temperature, params.MaxTokens, params.TopK, params.TopP)),
-	)
+		return c.predict(ctx, prompt, params, a)
+	}
+}
 
+// GenerateStream implements llms.StreamLLM.
+func (c client) GenerateStream(ctx context.Context, prompt string, params Params) (llms.Stream, error) {
+	params = withDefaults(params, "text-bison@001", 64)
+	a, err := apiFor(params.Model)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %v", err)
+		return nil, err
 	}
-	if c.key != "" {
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.key))
+
+	switch a {
+	case apiGemini:
+		return c.streamGenerateContent(ctx, []content{userContent(prompt)}, params)
+	case apiChat:
+		// Streaming is not supported for chat models, so return the whole
+		// response at once.
+		resp, err := c.Generate(ctx, prompt, params)
+		if err != nil {
+			return nil, err
+		}
+		return llms.NewStaticStream(resp), nil
+	default:
+		return c.streamPredict(ctx, prompt, params, a)
 	}
-	req.Header.Set("Content-Type", "application/json")
+}
 
-	resp, err := c.client.Do(req)
+// Chat implements llms.ChatLLM.
+func (c client) Chat(ctx context.Context, messages []llms.Message, params Params) (llms.Message, error) {
+	params = withDefaults(params, "chat-bison@001", 256)
+	a, err := apiFor(params.Model)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %v", err)
+		return llms.Message{}, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		data, err := ioutil.ReadAll(resp.Body)
+	switch a {
+	case apiGemini:
+		system, contents := geminiContents(messages)
+		results, err := c.generateContent(ctx, system, contents, nil, params)
 		if err != nil {
-			return "", fmt.Errorf("failed to read response: %v", err)
+			return llms.Message{}, err
 		}
-		return "", fmt.Errorf("request failed with status code %d: %s", resp.StatusCode, data)
+		return llms.Message{Role: llms.RoleAssistant, Content: results[0].Text}, nil
+	case apiChat:
+		results, err := c.chatPredict(ctx, messages, params)
+		if err != nil {
+			return llms.Message{}, err
+		}
+		return llms.Message{Role: llms.RoleAssistant, Content: results[0].Text}, nil
+	default:
+		// Text and code models don't understand conversations, so flatten it
+		// into a single prompt.
+		resp, err := c.Generate(ctx, llms.FlattenMessages(messages), params)
+		if err != nil {
+			return llms.Message{}, err
+		}
+		return llms.Message{Role: llms.RoleAssistant, Content: strings.TrimSpace(resp)}, nil
+	}
+}
+
+func (c client) url(model, method string) string {
+	return fmt.Sprintf(
+		"%s://%s/v1/projects/%s/locations/%s/publishers/google/models/%s:%s",
+		c.scheme,
+		c.apiEndpoint,
+		c.projectID,
+		c.location,
+		model,
+		method,
+	)
+}
+
+// do sends the request and decodes the JSON response into v. The raw response
+// is returned as well.
+func (c client) do(req *http.Request, v any) (json.RawMessage, error) {
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %v", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %v", err)
 	}
+	return data, nil
+}
 
-	var r response
-	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
-		return "", fmt.Errorf("failed to decode response: %v", err)
+// send sends the request and returns the response if it was successful. The
+// caller is responsible for closing the response body. Failures are classified
+// with the llms errors.
+func (c client) send(req *http.Request) (*http.Response, error) {
+	for k, v := range c.headers {
+		req.Header[k] = v
+	}
+	if c.key != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.key))
 	}
+	req.Header.Set("Content-Type", "application/json")
 
-	if len(r.Predictions) == 0 {
-		return "", fmt.Errorf("no predictions returned")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		// Network failures are worth retrying. The error is wrapped as well so
+		// that canceled requests can still be told apart.
+		return nil, fmt.Errorf("%w: failed to send request: %w", llms.ErrTransient, err)
 	}
 
-	return r.Predictions[0].Content, nil
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, newAPIError(resp)
+	}
+	return resp, nil
 }
 
-type response struct {
-	Predictions []struct {
-		Content string `json:"content"`
-	} `json:"predictions"`
+// withDefaults sets some defaults, using the given model and max tokens, if the
+// generation params are empty.
+func withDefaults(params Params, model string, maxTokens int) Params {
+	if params.Model == "" &&
+		params.MaxTokens == 0 &&
+		params.Temperature == 0 &&
+		params.TopK == 0 &&
+		params.TopP == 0 {
+		params.Model = model
+		params.MaxTokens = maxTokens
+		params.Temperature = 0.2
+		params.TopK = 40
+		params.TopP = 0.8
+	}
+	return params
 }
@@ -0,0 +1,1099 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vertex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-react/pkg/llms"
+)
+
+func newTestClient(t *testing.T, h http.HandlerFunc, opts ...Option) client {
+	t.Helper()
+	srv := httptest.NewTLSServer(h)
+	t.Cleanup(srv.Close)
+	return newClient(
+		"some-key",
+		strings.TrimPrefix(srv.URL, "https://"),
+		"some-project",
+		append([]Option{WithHTTPClient(srv.Client())}, opts...),
+	)
+}
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	var body map[string]any
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if actual, expected := r.URL.Path, "/v1/projects/some-project/locations/us-central1/publishers/google/models/text-bison@001:predict"; actual != expected {
+			t.Errorf("expected %q, got %q", expected, actual)
+		}
+		if actual, expected := r.Header.Get("Authorization"), "Bearer some-key"; actual != expected {
+			t.Errorf("expected %q, got %q", expected, actual)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		fmt.Fprint(w, `{"predictions": [{"content": "some-response"}]}`)
+	})
+
+	resp, err := c.Generate(context.Background(), "some-prompt", Params{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := resp, "some-response"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+	instance := body["instances"].([]any)[0].(map[string]any)
+	if actual, expected := instance["content"], "some-prompt"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestGenerate_error(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "some-error", http.StatusBadRequest)
+	})
+
+	if _, err := c.Generate(context.Background(), "some-prompt", Params{}); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestGenerateStream(t *testing.T) {
+	t.Parallel()
+
+	chunks := []string{"some-", "streamed-", "response"}
+	var body streamingRequest
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if actual, expected := r.URL.Path, "/v1/projects/some-project/locations/us-central1/publishers/google/models/text-bison@001:serverStreamingPredict"; actual != expected {
+			t.Errorf("expected %q, got %q", expected, actual)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+
+		// Write each chunk separately so that the response is chunked.
+		fmt.Fprint(w, "[")
+		for i, chunk := range chunks {
+			if i > 0 {
+				fmt.Fprint(w, ",\r\n")
+			}
+			fmt.Fprintf(w, `{"outputs": [{"structVal": {"content": {"stringVal": [%q]}}}]}`, chunk)
+			w.(http.Flusher).Flush()
+		}
+		fmt.Fprint(w, "]")
+	})
+
+	s, err := c.GenerateStream(context.Background(), "some-prompt", Params{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	var actual []string
+	for {
+		chunk, err := s.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual = append(actual, chunk)
+	}
+
+	if actual, expected := strings.Join(actual, "|"), strings.Join(chunks, "|"); actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+	if actual, expected := body.Inputs[0].StructVal["content"].StringVal[0], "some-prompt"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestGenerateStream_error(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "some-error", http.StatusServiceUnavailable)
+	})
+
+	if _, err := c.GenerateStream(context.Background(), "some-prompt", Params{}); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestChat(t *testing.T) {
+	t.Parallel()
+
+	var body chatRequest
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if actual, expected := r.URL.Path, "/v1/projects/some-project/locations/us-central1/publishers/google/models/chat-bison@001:predict"; actual != expected {
+			t.Errorf("expected %q, got %q", expected, actual)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		fmt.Fprint(w, `{"predictions": [{"candidates": [{"author": "bot", "content": "some-response"}]}]}`)
+	})
+
+	resp, err := c.Chat(context.Background(), []llms.Message{
+		{Role: llms.RoleSystem, Content: "some-context"},
+		{Role: llms.RoleUser, Content: "some-question"},
+		{Role: llms.RoleAssistant, Content: "some-answer"},
+	}, Params{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := resp, (llms.Message{Role: llms.RoleAssistant, Content: "some-response"}); actual != expected {
+		t.Fatalf("expected %+v, got %+v", expected, actual)
+	}
+
+	instance := body.Instances[0]
+	if actual, expected := instance.Context, "some-context"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+	if actual, expected := len(instance.Messages), 2; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+	if actual, expected := instance.Messages[1], (chatMessage{Author: "bot", Content: "some-answer"}); actual != expected {
+		t.Fatalf("expected %+v, got %+v", expected, actual)
+	}
+}
+
+// readFixture returns the contents of the file in testdata.
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// assertJSON fails the test if the JSON documents are not equivalent.
+func assertJSON(t *testing.T, expected, actual []byte) {
+	t.Helper()
+	var e, a any
+	if err := json.Unmarshal(expected, &e); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(actual, &a); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(a, e) {
+		t.Fatalf("expected %s, got %s", expected, actual)
+	}
+}
+
+func TestGenerate_models(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		model  string
+		method string
+		key    string
+	}{
+		{model: "text-bison@001", method: "predict", key: "content"},
+		{model: "text-unicorn@001", method: "predict", key: "content"},
+		{model: "code-bison@002", method: "predict", key: "prefix"},
+		{model: "code-gecko", method: "predict", key: "prefix"},
+		{model: "gemini-1.0-pro", method: "generateContent"},
+		{model: "gemini-1.5-flash-001", method: "generateContent"},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.model, func(t *testing.T) {
+			t.Parallel()
+
+			var body map[string]any
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if actual, expected := r.URL.Path, "/v1/projects/some-project/locations/us-central1/publishers/google/models/"+tc.model+":"+tc.method; actual != expected {
+					t.Errorf("expected %q, got %q", expected, actual)
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Error(err)
+				}
+				if tc.method == "generateContent" {
+					fmt.Fprint(w, `{"candidates": [{"content": {"parts": [{"text": "some-response"}]}}]}`)
+					return
+				}
+				fmt.Fprint(w, `{"predictions": [{"content": "some-response"}]}`)
+			})
+
+			resp, err := c.Generate(context.Background(), "some-prompt", Params{Model: tc.model})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual, expected := resp, "some-response"; actual != expected {
+				t.Fatalf("expected %q, got %q", expected, actual)
+			}
+			if tc.key == "" {
+				return
+			}
+			instance := body["instances"].([]any)[0].(map[string]any)
+			if actual, expected := instance[tc.key], "some-prompt"; actual != expected {
+				t.Fatalf("expected %q, got %q", expected, actual)
+			}
+		})
+	}
+}
+
+func TestGenerate_unsupportedModel(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("unexpected request")
+	})
+
+	if _, err := c.Generate(context.Background(), "some-prompt", Params{Model: "some-model"}); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestChat_gemini(t *testing.T) {
+	t.Parallel()
+
+	var body []byte
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if actual, expected := r.URL.Path, "/v1/projects/some-project/locations/us-central1/publishers/google/models/gemini-1.0-pro:generateContent"; actual != expected {
+			t.Errorf("expected %q, got %q", expected, actual)
+		}
+		var err error
+		if body, err = io.ReadAll(r.Body); err != nil {
+			t.Error(err)
+		}
+		w.Write(readFixture(t, "gemini_response.json"))
+	})
+
+	resp, err := c.Chat(context.Background(), []llms.Message{
+		{Role: llms.RoleSystem, Content: "You are a geography teacher."},
+		{Role: llms.RoleUser, Content: "What is the capital of France?"},
+		{Role: llms.RoleAssistant, Content: "Paris."},
+		{Role: llms.RoleUser, Content: "And of Germany?"},
+	}, Params{
+		Model:             "gemini-1.0-pro",
+		MaxTokens:         64,
+		Temperature:       0.2,
+		TopK:              40,
+		TopP:              0.8,
+		StopSequences:     []string{"\n"},
+		Seed:              7,
+		SystemInstruction: "Answer in one word.",
+		SafetySettings: []SafetySetting{
+			{Category: "HARM_CATEGORY_DANGEROUS_CONTENT", Threshold: "BLOCK_ONLY_HIGH"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := resp, (llms.Message{Role: llms.RoleAssistant, Content: "Berlin."}); actual != expected {
+		t.Fatalf("expected %+v, got %+v", expected, actual)
+	}
+	assertJSON(t, readFixture(t, "gemini_request.json"), body)
+}
+
+func TestGenerate_geminiBlocked(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write(readFixture(t, "gemini_blocked_response.json"))
+	})
+
+	_, err := c.Generate(context.Background(), "some-prompt", Params{Model: "gemini-1.0-pro"})
+	if actual, expected := errors.Is(err, llms.ErrSafetyBlocked), true; actual != expected {
+		t.Fatalf("expected %v, got %v: %v", expected, actual, err)
+	}
+}
+
+func TestGenerateStream_gemini(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if actual, expected := r.URL.Path, "/v1/projects/some-project/locations/us-central1/publishers/google/models/gemini-1.0-pro:streamGenerateContent"; actual != expected {
+			t.Errorf("expected %q, got %q", expected, actual)
+		}
+		if actual, expected := r.URL.Query().Get("alt"), "sse"; actual != expected {
+			t.Errorf("expected %q, got %q", expected, actual)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write(readFixture(t, "gemini_stream.txt"))
+	})
+
+	s, err := c.GenerateStream(context.Background(), "some-prompt", Params{Model: "gemini-1.0-pro"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := llms.ReadStream(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := resp, "The capital of Germany is Berlin."; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestGenerateStream_geminiBlocked(t *testing.T) {
+	t.Parallel()
+
+	for _, reason := range []string{"SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII"} {
+		// Avoid issues with closure.
+		reason := reason
+		t.Run(reason, func(t *testing.T) {
+			t.Parallel()
+
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				fmt.Fprintf(w, "data: {\"candidates\": [{\"finishReason\": %q}]}\n\n", reason)
+			})
+
+			s, err := c.GenerateStream(context.Background(), "some-prompt", Params{Model: "gemini-1.0-pro"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = llms.ReadStream(s)
+			if actual, expected := errors.Is(err, llms.ErrSafetyBlocked), true; actual != expected {
+				t.Fatalf("expected %v, got %v: %v", expected, actual, err)
+			}
+		})
+	}
+}
+
+func TestGenerateStream_geminiBlockedMidStream(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"candidates\": [{\"content\": {\"parts\": [{\"text\": \"some-\"}]}}]}\n\n")
+		fmt.Fprint(w, "data: {\"candidates\": [{\"content\": {\"parts\": [{\"text\": \"text\"}]}, \"finishReason\": \"RECITATION\"}]}\n\n")
+	})
+
+	s, err := c.GenerateStream(context.Background(), "some-prompt", Params{Model: "gemini-1.0-pro"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// The text generated before the response was blocked is returned first.
+	for _, expected := range []string{"some-", "text"} {
+		chunk, err := s.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := chunk; actual != expected {
+			t.Fatalf("expected %q, got %q", expected, actual)
+		}
+	}
+	if _, err := s.Recv(); !errors.Is(err, llms.ErrSafetyBlocked) {
+		t.Fatalf("expected %v, got %v", llms.ErrSafetyBlocked, err)
+	}
+}
+
+func TestGenerateStream_geminiLargeEvent(t *testing.T) {
+	t.Parallel()
+
+	text := strings.Repeat("a", 1<<20)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "data: {\"candidates\": [{\"content\": {\"parts\": [{\"text\": %q}]}}]}\n\n", text)
+	})
+
+	s, err := c.GenerateStream(context.Background(), "some-prompt", Params{Model: "gemini-1.0-pro"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := llms.ReadStream(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := len(resp), len(text); actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+}
+
+func TestGenerate_geminiBlockedWithText(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"candidates": [{"content": {"role": "model", "parts": [{"text": "some-partial-response"}]}, "finishReason": "SAFETY"}]}`)
+	})
+
+	_, err := c.Generate(context.Background(), "some-prompt", Params{Model: "gemini-1.0-pro"})
+	if actual, expected := errors.Is(err, llms.ErrSafetyBlocked), true; actual != expected {
+		t.Fatalf("expected %v, got %v: %v", expected, actual, err)
+	}
+}
+
+func TestGenerate_nilHTTPClient(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"predictions": [{"content": "some-response"}]}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	llm := NewWithKey("some-key", strings.TrimPrefix(srv.URL, "http://"), "some-project", WithHTTPClient(nil), WithPlainHTTP())
+	resp, err := llm.Generate(context.Background(), "some-prompt", Params{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := resp, "some-response"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestGenerate_options(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if actual, expected := r.URL.Path, "/v1/projects/some-project/locations/europe-west4/publishers/google/models/text-bison@001:predict"; actual != expected {
+			t.Errorf("expected %q, got %q", expected, actual)
+		}
+		if actual, expected := r.Header.Get("X-Some-Header"), "some-value"; actual != expected {
+			t.Errorf("expected %q, got %q", expected, actual)
+		}
+		fmt.Fprint(w, `{"predictions": [{"content": "some-response"}]}`)
+	},
+		WithLocation("europe-west4"),
+		WithHeader("X-Some-Header", "some-value"),
+	)
+
+	if _, err := c.Generate(context.Background(), "some-prompt", Params{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewWithKey_apiEndpoint(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		apiEndpoint string
+		opts        []Option
+		expected    string
+	}{
+		{
+			name:     "default",
+			expected: "https://us-central1-aiplatform.googleapis.com/v1/projects/some-project/locations/us-central1/publishers/google/models/some-model:predict",
+		},
+		{
+			name:     "location",
+			opts:     []Option{WithLocation("europe-west4")},
+			expected: "https://europe-west4-aiplatform.googleapis.com/v1/projects/some-project/locations/europe-west4/publishers/google/models/some-model:predict",
+		},
+		{
+			name:        "api endpoint",
+			apiEndpoint: "some-endpoint",
+			opts:        []Option{WithLocation("europe-west4")},
+			expected:    "https://some-endpoint/v1/projects/some-project/locations/europe-west4/publishers/google/models/some-model:predict",
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := NewWithKey("some-key", tc.apiEndpoint, "some-project", tc.opts...).(client)
+			if actual, expected := c.url("some-model", "predict"), tc.expected; actual != expected {
+				t.Fatalf("expected %q, got %q", expected, actual)
+			}
+		})
+	}
+}
+
+func TestGenerate_params(t *testing.T) {
+	t.Parallel()
+
+	// Go escaping (e.g., \x00 and \U0001f600) is not valid JSON, so make sure
+	// the prompt makes it through intact.
+	prompt := "some-prompt \x00 \"quoted\" \U0001f600 \u2028"
+
+	var body predictRequest
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		fmt.Fprint(w, `{"predictions": [{"content": "some-response"}]}`)
+	})
+
+	params := Params{
+		Model:          "text-bison@002",
+		MaxTokens:      10,
+		Temperature:    0.5,
+		TopK:           20,
+		TopP:           0.9,
+		StopSequences:  []string{"\n", "Observation:"},
+		CandidateCount: 2,
+		Seed:           7,
+	}
+	if _, err := c.Generate(context.Background(), prompt, params); err != nil {
+		t.Fatal(err)
+	}
+
+	if actual, expected := body.Instances[0]["content"], prompt; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+	if actual, expected := body.Parameters, newParameters(params); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, actual)
+	}
+}
+
+func TestGenerate_generationConfig(t *testing.T) {
+	t.Parallel()
+
+	var body geminiRequest
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		fmt.Fprint(w, `{"candidates": [{"content": {"role": "model", "parts": [{"text": "{}"}]}, "finishReason": "STOP"}]}`)
+	})
+
+	llm := llms.MapParams[llms.GenerationConfig](c, FromGenerationConfig)
+	if _, err := llm.Generate(context.Background(), "some-prompt", llms.GenerationConfig{
+		Model:         "gemini-1.5-pro",
+		Temperature:   0.5,
+		TopK:          20,
+		TopP:          0.9,
+		MaxTokens:     10,
+		StopSequences: []string{"\n"},
+		Seed:          7,
+		JSON:          true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := generationConfig{
+		Temperature:      0.5,
+		MaxOutputTokens:  10,
+		TopK:             20,
+		TopP:             0.9,
+		StopSequences:    []string{"\n"},
+		Seed:             7,
+		ResponseMIMEType: "application/json",
+	}
+	if actual := body.GenerationConfig; !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, actual)
+	}
+}
+
+func TestGenerateWithMetadata(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		model    string
+		response string
+		expected llms.Result
+	}{
+		{
+			name:     "gemini",
+			model:    "gemini-1.0-pro",
+			response: "gemini_response.json",
+			expected: llms.Result{
+				Text:         "Berlin.",
+				Usage:        llms.Usage{InputTokens: 21, OutputTokens: 2},
+				FinishReason: llms.FinishReasonStop,
+				SafetyRatings: []llms.SafetyRating{
+					{Category: "HARM_CATEGORY_DANGEROUS_CONTENT", Probability: "NEGLIGIBLE"},
+				},
+			},
+		},
+		{
+			name:     "palm",
+			model:    "text-bison@002",
+			response: "palm_response.json",
+			expected: llms.Result{
+				Text:  "Berlin.",
+				Usage: llms.Usage{InputTokens: 9, OutputTokens: 3},
+				SafetyRatings: []llms.SafetyRating{
+					{Category: "Finance", Probability: "0.1"},
+					{Category: "Health", Probability: "0.2"},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			response := readFixture(t, tc.response)
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Write(response)
+			})
+
+			result, err := c.GenerateWithMetadata(context.Background(), "some-prompt", Params{Model: tc.model})
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertJSON(t, response, result.Raw)
+			result.Raw = nil
+			if actual, expected := result, tc.expected; !reflect.DeepEqual(actual, expected) {
+				t.Fatalf("expected %+v, got %+v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestGenerateWithMetadata_maxTokens(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"candidates": [{"content": {"parts": [{"text": "{\"name\": "}]}, "finishReason": "MAX_TOKENS"}]}`)
+	})
+
+	result, err := c.GenerateWithMetadata(context.Background(), "some-prompt", Params{Model: "gemini-1.0-pro"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := result.FinishReason, llms.FinishReasonMaxTokens; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestGenerateCandidates(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		model    string
+		response string
+		expected []string
+	}{
+		{
+			name:     "gemini",
+			model:    "gemini-1.0-pro",
+			response: `{"candidates": [{"content": {"parts": [{"text": "some-response"}]}}, {"finishReason": "SAFETY"}, {"content": {"parts": [{"text": "other-response"}]}}]}`,
+			expected: []string{"some-response", "other-response"},
+		},
+		{
+			name:     "palm",
+			model:    "text-bison@002",
+			response: `{"predictions": [{"content": "some-response"}, {"content": "other-response"}]}`,
+			expected: []string{"some-response", "other-response"},
+		},
+		{
+			name:     "palm chat",
+			model:    "chat-bison@002",
+			response: `{"predictions": [{"candidates": [{"author": "bot", "content": "some-response"}, {"author": "bot", "content": "other-response"}]}]}`,
+			expected: []string{"some-response", "other-response"},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var body string
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				data, err := io.ReadAll(r.Body)
+				if err != nil {
+					t.Error(err)
+				}
+				body = string(data)
+				fmt.Fprint(w, tc.response)
+			})
+
+			results, err := c.GenerateCandidates(context.Background(), "some-prompt", Params{Model: tc.model}, 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var actual []string
+			for _, r := range results {
+				actual = append(actual, r.Text)
+			}
+			if actual, expected := strings.Join(actual, "|"), strings.Join(tc.expected, "|"); actual != expected {
+				t.Fatalf("expected %q, got %q", expected, actual)
+			}
+			if actual, expected := strings.Contains(body, `"candidateCount":3`), true; actual != expected {
+				t.Fatalf("expected candidateCount to be sent: %s", body)
+			}
+		})
+	}
+}
+
+func TestGenerate_errors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		status     int
+		retryAfter string
+		body       string
+		expected   error
+		retryable  bool
+	}{
+		{
+			name:       "quota exceeded",
+			status:     http.StatusTooManyRequests,
+			retryAfter: "7",
+			body:       `{"error": {"code": 429, "message": "Quota exceeded for aiplatform.googleapis.com/generate_content_requests_per_minute_per_project_per_base_model.", "status": "RESOURCE_EXHAUSTED"}}`,
+			expected:   llms.ErrRateLimited,
+			retryable:  true,
+		},
+		{
+			name:      "unavailable",
+			status:    http.StatusServiceUnavailable,
+			body:      `{"error": {"code": 503, "message": "The service is currently unavailable.", "status": "UNAVAILABLE"}}`,
+			expected:  llms.ErrTransient,
+			retryable: true,
+		},
+		{
+			name:      "gateway error without a body",
+			status:    http.StatusBadGateway,
+			body:      `some-gateway-error`,
+			expected:  llms.ErrTransient,
+			retryable: true,
+		},
+		{
+			name:     "invalid argument",
+			status:   http.StatusBadRequest,
+			body:     `{"error": {"code": 400, "message": "Unable to submit request because it has a topK value of 50 but the supported range is from 1 (inclusive) to 41 (exclusive).", "status": "INVALID_ARGUMENT"}}`,
+			expected: llms.ErrInvalidRequest,
+		},
+		{
+			name:     "context length exceeded",
+			status:   http.StatusBadRequest,
+			body:     `{"error": {"code": 400, "message": "The input token count (40000) exceeds the maximum number of tokens allowed (32768).", "status": "INVALID_ARGUMENT"}}`,
+			expected: llms.ErrContextLength,
+		},
+		{
+			name:     "permission denied",
+			status:   http.StatusForbidden,
+			body:     `{"error": {"code": 403, "message": "Permission 'aiplatform.endpoints.predict' denied.", "status": "PERMISSION_DENIED"}}`,
+			expected: llms.ErrAuth,
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if tc.retryAfter != "" {
+					w.Header().Set("Retry-After", tc.retryAfter)
+				}
+				w.WriteHeader(tc.status)
+				fmt.Fprint(w, tc.body)
+			})
+
+			_, err := c.Generate(context.Background(), "some-prompt", Params{Model: "gemini-1.0-pro"})
+			if actual, expected := errors.Is(err, tc.expected), true; actual != expected {
+				t.Fatalf("expected %v, got %v: %v", expected, actual, err)
+			}
+			if actual, expected := llms.IsRetryable(err), tc.retryable; actual != expected {
+				t.Fatalf("expected %v, got %v", expected, actual)
+			}
+
+			var apiErr *llms.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected an APIError, got %T", err)
+			}
+			if actual, expected := apiErr.StatusCode, tc.status; actual != expected {
+				t.Fatalf("expected %d, got %d", expected, actual)
+			}
+			if tc.retryAfter != "" {
+				if actual, expected := apiErr.RetryAfter, 7*time.Second; actual != expected {
+					t.Fatalf("expected %v, got %v", expected, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestCountTokens(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		model    string
+		instance bool
+	}{
+		{model: "text-bison@001", instance: true},
+		{model: "chat-bison@001", instance: true},
+		{model: "gemini-1.0-pro"},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.model, func(t *testing.T) {
+			t.Parallel()
+
+			var body map[string]any
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if actual, expected := r.URL.Path, "/v1/projects/some-project/locations/us-central1/publishers/google/models/"+tc.model+":countTokens"; actual != expected {
+					t.Errorf("expected %q, got %q", expected, actual)
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Error(err)
+				}
+				fmt.Fprint(w, `{"totalTokens": 7, "totalBillableCharacters": 22}`)
+			})
+
+			n, err := llms.CountTokens[Params](context.Background(), c, "some-prompt", Params{
+				Model:          tc.model,
+				SafetySettings: []SafetySetting{{Category: "some-category", Threshold: "some-threshold"}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual, expected := n, 7; actual != expected {
+				t.Fatalf("expected %d, got %d", expected, actual)
+			}
+
+			if _, ok := body["instances"]; ok != tc.instance {
+				t.Fatalf("expected instances to be sent: %v", tc.instance)
+			}
+			if _, ok := body["safetySettings"]; ok {
+				t.Fatal("expected safetySettings to be omitted")
+			}
+		})
+	}
+}
+
+func TestModelInfo(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		model    string
+		expected llms.ModelInfo
+		found    bool
+	}{
+		{model: "", expected: llms.ModelInfo{ContextWindow: 8192, MaxOutputTokens: 1024}, found: true},
+		{model: "text-bison@002", expected: llms.ModelInfo{ContextWindow: 8192, MaxOutputTokens: 1024}, found: true},
+		{model: "text-bison-32k", expected: llms.ModelInfo{ContextWindow: 32768, MaxOutputTokens: 8192}, found: true},
+		{model: "gemini-1.5-flash-001", expected: llms.ModelInfo{ContextWindow: 1048576, MaxOutputTokens: 8192}, found: true},
+		{model: "gemini-1.0-pro-vision", expected: llms.ModelInfo{ContextWindow: 12288, MaxOutputTokens: 4096}, found: true},
+		{model: "some-model", found: false},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.model, func(t *testing.T) {
+			t.Parallel()
+
+			info, ok := llms.LookupModelInfo[Params](client{}, Params{Model: tc.model})
+			if actual, expected := ok, tc.found; actual != expected {
+				t.Fatalf("expected %v, got %v", expected, actual)
+			}
+			if actual, expected := info, tc.expected; actual != expected {
+				t.Fatalf("expected %+v, got %+v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestEmbed(t *testing.T) {
+	t.Parallel()
+
+	var batches []embeddingRequest
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if actual, expected := r.URL.Path, "/v1/projects/some-project/locations/us-central1/publishers/google/models/text-embedding-004:predict"; actual != expected {
+			t.Errorf("expected %q, got %q", expected, actual)
+		}
+		var body embeddingRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		batches = append(batches, body)
+
+		// Each embedding holds the length of its text, so that the order can
+		// be checked.
+		var predictions []any
+		for _, instance := range body.Instances {
+			predictions = append(predictions, map[string]any{
+				"embeddings": map[string]any{"values": []int{len(instance.Content), 1}},
+			})
+		}
+		resp := map[string]any{"predictions": predictions}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			t.Error(err)
+		}
+	})
+
+	// More texts than fit in a single request.
+	var texts []string
+	for i := 0; i < maxEmbeddingBatch+1; i++ {
+		texts = append(texts, strings.Repeat("a", i%10))
+	}
+	embeddings, err := c.Embed(context.Background(), texts, llms.TaskRetrievalQuery, EmbeddingParams{Dimensions: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if actual, expected := len(embeddings), len(texts); actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+	for i, e := range embeddings {
+		if actual, expected := e[0], float32(i%10); actual != expected {
+			t.Fatalf("%d: expected %v, got %v", i, expected, actual)
+		}
+	}
+
+	if actual, expected := len(batches), 2; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+	if actual, expected := batches[0].Instances[0].TaskType, "RETRIEVAL_QUERY"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+	if actual, expected := batches[0].Parameters.OutputDimensionality, 2; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+}
+
+func TestGenerateWithFunctions(t *testing.T) {
+	t.Parallel()
+
+	var body map[string]any
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		fmt.Fprint(w, `{
+			"candidates": [{
+				"content": {"role": "model", "parts": [{"functionCall": {"name": "some-tool", "args": {"input": "some-input"}}}]},
+				"finishReason": "STOP"
+			}],
+			"usageMetadata": {"promptTokenCount": 5, "candidatesTokenCount": 2}
+		}`)
+	})
+
+	result, err := c.GenerateWithFunctions(context.Background(), "some-prompt", []llms.FunctionDeclaration{{
+		Name:        "some-tool",
+		Description: "some-description",
+		Parameters:  json.RawMessage(`{"type": "object"}`),
+	}}, Params{Model: "gemini-1.0-pro", FunctionCallingMode: "ANY"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if actual, expected := len(result.FunctionCalls), 1; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+	if actual, expected := result.FunctionCalls[0].Name, "some-tool"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+	assertJSON(t, []byte(`{"input": "some-input"}`), result.FunctionCalls[0].Args)
+
+	tools := body["tools"].([]any)
+	declaration := tools[0].(map[string]any)["functionDeclarations"].([]any)[0].(map[string]any)
+	if actual, expected := declaration["name"], "some-tool"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+	mode := body["toolConfig"].(map[string]any)["functionCallingConfig"].(map[string]any)["mode"]
+	if actual, expected := mode, "ANY"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestGenerateWithFunctions_unsupportedModel(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("unexpected request")
+	})
+
+	_, err := c.GenerateWithFunctions(context.Background(), "some-prompt", nil, Params{Model: "text-bison@001"})
+	if actual, expected := errors.Is(err, llms.ErrFunctionCallingUnsupported), true; actual != expected {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestGenerateMultimodal(t *testing.T) {
+	t.Parallel()
+
+	var body map[string]any
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		fmt.Fprint(w, `{"candidates": [{"content": {"role": "model", "parts": [{"text": "some-response"}]}, "finishReason": "STOP"}]}`)
+	})
+
+	result, err := c.GenerateMultimodal(context.Background(), []llms.Part{
+		llms.TextPart("some-prompt"),
+		llms.DataPart("image/png", []byte("some-image")),
+		llms.FilePart("application/pdf", "gs://some-bucket/some-file.pdf"),
+	}, Params{Model: "gemini-1.0-pro-vision"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := result.Text, "some-response"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+
+	contents, err := json.Marshal(body["contents"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, []byte(`[{"role": "user", "parts": [
+		{"text": "some-prompt"},
+		{"inlineData": {"mimeType": "image/png", "data": "c29tZS1pbWFnZQ=="}},
+		{"fileData": {"mimeType": "application/pdf", "fileUri": "gs://some-bucket/some-file.pdf"}}
+	]}]`), contents)
+}
+
+func TestGenerateMultimodal_palm(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"predictions": [{"content": "some-response"}]}`)
+	})
+
+	// Text parts are joined into a single prompt.
+	result, err := c.GenerateMultimodal(context.Background(), []llms.Part{
+		llms.TextPart("some-"),
+		llms.TextPart("prompt"),
+	}, Params{Model: "text-bison@001"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := result.Text, "some-response"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+
+	_, err = c.GenerateMultimodal(context.Background(), []llms.Part{
+		llms.DataPart("image/png", []byte("some-image")),
+	}, Params{Model: "text-bison@001"})
+	if actual, expected := errors.Is(err, llms.ErrMultimodalUnsupported), true; actual != expected {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+}
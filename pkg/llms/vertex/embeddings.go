@@ -0,0 +1,165 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vertex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-react/pkg/llms"
+)
+
+// DefaultEmbeddingModel is the model used when EmbeddingParams.Model is empty.
+const DefaultEmbeddingModel = "text-embedding-004"
+
+// maxEmbeddingBatch is the max number of texts the API embeds in a single
+// request.
+const maxEmbeddingBatch = 250
+
+// EmbeddingParams are the parameters for an embeddings request.
+type EmbeddingParams struct {
+	// Model is a text embedding model (e.g., text-embedding-004). It defaults
+	// to DefaultEmbeddingModel.
+	Model string
+	// Dimensions reduces the size of the embeddings. The model's default is
+	// used when it is zero.
+	Dimensions int
+	// AutoTruncate truncates texts that are too long instead of failing.
+	AutoTruncate bool
+}
+
+// NewEmbedder returns a new Google Vertex AI Embedder that uses the
+// application default credentials. If the apiEndpoint is empty, the
+// location's endpoint is used.
+func NewEmbedder(ctx context.Context, apiEndpoint, projectID string, opts ...Option) (llms.Embedder[EmbeddingParams], error) {
+	llm, err := New(ctx, apiEndpoint, projectID, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return llm.(client), nil
+}
+
+// NewEmbedderWithKey returns a new Google Vertex AI Embedder that uses the
+// given access token. If the apiEndpoint is empty, the location's endpoint is
+// used.
+func NewEmbedderWithKey(key, apiEndpoint, projectID string, opts ...Option) llms.Embedder[EmbeddingParams] {
+	return newClient(key, apiEndpoint, projectID, opts)
+}
+
+// Embed implements llms.Embedder. The texts are sent in batches of up to 250.
+func (c client) Embed(ctx context.Context, texts []string, task llms.TaskType, params EmbeddingParams) ([]llms.Embedding, error) {
+	if params.Model == "" {
+		params.Model = DefaultEmbeddingModel
+	}
+
+	embeddings := make([]llms.Embedding, 0, len(texts))
+	for start := 0; start < len(texts); start += maxEmbeddingBatch {
+		end := start + maxEmbeddingBatch
+		if end > len(texts) {
+			end = len(texts)
+		}
+
+		batch, err := c.embed(ctx, texts[start:end], task, params)
+		if err != nil {
+			return nil, err
+		}
+		embeddings = append(embeddings, batch...)
+	}
+	return embeddings, nil
+}
+
+func (c client) embed(ctx context.Context, texts []string, task llms.TaskType, params EmbeddingParams) ([]llms.Embedding, error) {
+	r := embeddingRequest{
+		Parameters: embeddingParameters{
+			AutoTruncate:         params.AutoTruncate,
+			OutputDimensionality: params.Dimensions,
+		},
+	}
+	for _, text := range texts {
+		r.Instances = append(r.Instances, embeddingInstance{
+			Content:  text,
+			TaskType: taskType(task),
+		})
+	}
+
+	body, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url(params.Model, "predict"), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	var resp embeddingResponse
+	if _, err := c.do(req, &resp); err != nil {
+		return nil, err
+	}
+
+	if len(resp.Predictions) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(resp.Predictions))
+	}
+
+	var embeddings []llms.Embedding
+	for _, p := range resp.Predictions {
+		embeddings = append(embeddings, p.Embeddings.Values)
+	}
+	return embeddings, nil
+}
+
+// taskType converts the task type to the API's.
+func taskType(task llms.TaskType) string {
+	switch task {
+	case llms.TaskRetrievalQuery:
+		return "RETRIEVAL_QUERY"
+	case llms.TaskRetrievalDocument:
+		return "RETRIEVAL_DOCUMENT"
+	case llms.TaskSemanticSimilarity:
+		return "SEMANTIC_SIMILARITY"
+	case llms.TaskClassification:
+		return "CLASSIFICATION"
+	case llms.TaskClustering:
+		return "CLUSTERING"
+	default:
+		return ""
+	}
+}
+
+type embeddingRequest struct {
+	Instances  []embeddingInstance `json:"instances"`
+	Parameters embeddingParameters `json:"parameters"`
+}
+
+type embeddingInstance struct {
+	Content  string `json:"content"`
+	TaskType string `json:"task_type,omitempty"`
+}
+
+type embeddingParameters struct {
+	AutoTruncate         bool `json:"autoTruncate"`
+	OutputDimensionality int  `json:"outputDimensionality,omitempty"`
+}
+
+type embeddingResponse struct {
+	Predictions []struct {
+		Embeddings struct {
+			Values llms.Embedding `json:"values"`
+		} `json:"embeddings"`
+	} `json:"predictions"`
+}
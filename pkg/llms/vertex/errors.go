@@ -0,0 +1,73 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vertex
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/google/go-react/pkg/llms"
+)
+
+// contextLengthPattern matches the messages Vertex AI uses when the prompt is
+// too long for the model.
+var contextLengthPattern = regexp.MustCompile(`(?i)token.*exceeds|exceeds.*tokens|too long`)
+
+// newAPIError reads the error from the response body and classifies it using
+// the status Vertex AI responds with, falling back to the status code.
+func newAPIError(resp *http.Response) error {
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%w: failed to read response: %v", llms.ErrTransient, err)
+	}
+
+	apiErr := &llms.APIError{
+		StatusCode: resp.StatusCode,
+		Message:    string(data),
+		RetryAfter: llms.ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+
+	var body struct {
+		Error *struct {
+			Message string `json:"message"`
+			Status  string `json:"status"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &body); err == nil && body.Error != nil {
+		apiErr.Message = body.Error.Message
+		apiErr.Status = body.Error.Status
+	}
+
+	switch apiErr.Status {
+	case "RESOURCE_EXHAUSTED":
+		apiErr.Err = llms.ErrRateLimited
+	case "UNAVAILABLE", "INTERNAL", "DEADLINE_EXCEEDED", "ABORTED":
+		apiErr.Err = llms.ErrTransient
+	case "UNAUTHENTICATED", "PERMISSION_DENIED":
+		apiErr.Err = llms.ErrAuth
+	case "INVALID_ARGUMENT", "FAILED_PRECONDITION", "NOT_FOUND", "OUT_OF_RANGE":
+		apiErr.Err = llms.ErrInvalidRequest
+	default:
+		apiErr.Err = llms.ClassifyStatus(resp.StatusCode)
+	}
+	if apiErr.Err == llms.ErrInvalidRequest && contextLengthPattern.MatchString(apiErr.Message) {
+		apiErr.Err = llms.ErrContextLength
+	}
+	return apiErr
+}
@@ -0,0 +1,367 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vertex
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-react/pkg/llms"
+)
+
+// generateContent generates text with the Gemini generateContent API.
+func (c client) generateContent(ctx context.Context, system []string, contents []content, functions []llms.FunctionDeclaration, params Params) ([]llms.Result, error) {
+	req, err := c.newGeminiRequest(ctx, "generateContent", system, contents, functions, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var r geminiResponse
+	raw, err := c.do(req, &r)
+	if err != nil {
+		return nil, err
+	}
+	return r.results(raw)
+}
+
+// finishReason converts the Gemini finish reason.
+func finishReason(reason string) llms.FinishReason {
+	switch reason {
+	case "":
+		return llms.FinishReasonUnknown
+	case "STOP":
+		return llms.FinishReasonStop
+	case "MAX_TOKENS":
+		return llms.FinishReasonMaxTokens
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII":
+		return llms.FinishReasonSafety
+	default:
+		return llms.FinishReasonOther
+	}
+}
+
+// streamGenerateContent streams text with the Gemini streamGenerateContent
+// API.
+func (c client) streamGenerateContent(ctx context.Context, contents []content, params Params) (llms.Stream, error) {
+	req, err := c.newGeminiRequest(ctx, "streamGenerateContent?alt=sse", nil, contents, nil, params)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(nil, maxEventSize)
+	return &geminiStream{
+		body:    resp.Body,
+		scanner: scanner,
+	}, nil
+}
+
+func (c client) newGeminiRequest(ctx context.Context, method string, system []string, contents []content, functions []llms.FunctionDeclaration, params Params) (*http.Request, error) {
+	if params.SystemInstruction != "" {
+		system = append(system, params.SystemInstruction)
+	}
+
+	r := geminiRequest{
+		Contents: contents,
+		GenerationConfig: generationConfig{
+			Temperature:      params.Temperature,
+			MaxOutputTokens:  params.MaxTokens,
+			TopK:             params.TopK,
+			TopP:             params.TopP,
+			StopSequences:    params.StopSequences,
+			CandidateCount:   params.CandidateCount,
+			Seed:             params.Seed,
+			ResponseMIMEType: params.ResponseMIMEType,
+		},
+		SafetySettings: params.SafetySettings,
+	}
+	if len(functions) > 0 {
+		r.Tools = []tool{{FunctionDeclarations: functions}}
+		if params.FunctionCallingMode != "" {
+			r.ToolConfig = &toolConfig{}
+			r.ToolConfig.FunctionCallingConfig.Mode = params.FunctionCallingMode
+		}
+	}
+	if len(system) > 0 {
+		r.SystemInstruction = &content{}
+		for _, s := range system {
+			r.SystemInstruction.Parts = append(r.SystemInstruction.Parts, part{Text: s})
+		}
+	}
+
+	body, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url(params.Model, method), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	return req, nil
+}
+
+// maxEventSize is the size of the largest server-sent event a geminiStream
+// reads.
+const maxEventSize = 16 << 20
+
+// geminiStream reads the server-sent events of a streamed generateContent
+// response. Each event holds the next chunk of text.
+type geminiStream struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+	// err is returned by the next Recv (e.g., when the response was blocked
+	// after the chunk that was returned).
+	err error
+}
+
+// Recv implements llms.Stream.
+func (s *geminiStream) Recv() (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+	for s.scanner.Scan() {
+		data, ok := strings.CutPrefix(s.scanner.Text(), "data:")
+		if !ok {
+			// Skip blank lines and other fields.
+			continue
+		}
+
+		var r geminiResponse
+		if err := json.Unmarshal([]byte(data), &r); err != nil {
+			return "", fmt.Errorf("failed to decode response: %v", err)
+		}
+		if len(r.Candidates) == 0 && r.PromptFeedback.BlockReason == "" {
+			// The last event may only hold the usage metadata.
+			continue
+		}
+		chunk, err := r.text()
+		if err != nil {
+			if chunk == "" {
+				return "", err
+			}
+			// Return the text generated before the response was blocked first.
+			s.err = err
+			return chunk, nil
+		}
+		if chunk != "" {
+			return chunk, nil
+		}
+	}
+	if err := s.scanner.Err(); err != nil {
+		return "", fmt.Errorf("%w: failed to read response: %v", llms.ErrTransient, err)
+	}
+	return "", io.EOF
+}
+
+// Close implements llms.Stream.
+func (s *geminiStream) Close() error {
+	return s.body.Close()
+}
+
+// userContent returns the content for a single user prompt.
+func userContent(prompt string) content {
+	return content{Role: "user", Parts: []part{{Text: prompt}}}
+}
+
+// multimodalContent returns the content for a multimodal user prompt.
+func multimodalContent(parts []llms.Part) content {
+	c := content{Role: "user"}
+	for _, p := range parts {
+		switch {
+		case p.Data != nil:
+			c.Parts = append(c.Parts, part{InlineData: &blob{MIMEType: p.MIMEType, Data: p.Data}})
+		case p.FileURI != "":
+			c.Parts = append(c.Parts, part{FileData: &fileData{MIMEType: p.MIMEType, FileURI: p.FileURI}})
+		default:
+			c.Parts = append(c.Parts, part{Text: p.Text})
+		}
+	}
+	return c
+}
+
+// geminiContents converts the messages to the system instruction and the
+// contents of a generateContent request.
+func geminiContents(messages []llms.Message) ([]string, []content) {
+	var (
+		system   []string
+		contents []content
+	)
+	for _, m := range messages {
+		switch m.Role {
+		case llms.RoleSystem:
+			system = append(system, m.Content)
+		case llms.RoleAssistant:
+			contents = append(contents, content{Role: "model", Parts: []part{{Text: m.Content}}})
+		default:
+			// Tool output is sent as if the user wrote it.
+			contents = append(contents, userContent(m.Content))
+		}
+	}
+	return system, contents
+}
+
+type geminiRequest struct {
+	Contents          []content        `json:"contents"`
+	SystemInstruction *content         `json:"systemInstruction,omitempty"`
+	GenerationConfig  generationConfig `json:"generationConfig"`
+	SafetySettings    []SafetySetting  `json:"safetySettings,omitempty"`
+	Tools             []tool           `json:"tools,omitempty"`
+	ToolConfig        *toolConfig      `json:"toolConfig,omitempty"`
+}
+
+type tool struct {
+	FunctionDeclarations []llms.FunctionDeclaration `json:"functionDeclarations"`
+}
+
+type toolConfig struct {
+	FunctionCallingConfig struct {
+		Mode string `json:"mode"`
+	} `json:"functionCallingConfig"`
+}
+
+type content struct {
+	Role  string `json:"role,omitempty"`
+	Parts []part `json:"parts"`
+}
+
+type part struct {
+	Text         string             `json:"text,omitempty"`
+	InlineData   *blob              `json:"inlineData,omitempty"`
+	FileData     *fileData          `json:"fileData,omitempty"`
+	FunctionCall *llms.FunctionCall `json:"functionCall,omitempty"`
+}
+
+type blob struct {
+	MIMEType string `json:"mimeType"`
+	Data     []byte `json:"data"`
+}
+
+type fileData struct {
+	MIMEType string `json:"mimeType"`
+	FileURI  string `json:"fileUri"`
+}
+
+type generationConfig struct {
+	Temperature      float64  `json:"temperature"`
+	MaxOutputTokens  int      `json:"maxOutputTokens,omitempty"`
+	TopK             int      `json:"topK,omitempty"`
+	TopP             float64  `json:"topP,omitempty"`
+	StopSequences    []string `json:"stopSequences,omitempty"`
+	CandidateCount   int      `json:"candidateCount,omitempty"`
+	Seed             int      `json:"seed,omitempty"`
+	ResponseMIMEType string   `json:"responseMimeType,omitempty"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content       content        `json:"content"`
+		FinishReason  string         `json:"finishReason"`
+		SafetyRatings []safetyRating `json:"safetyRatings"`
+	} `json:"candidates"`
+	PromptFeedback struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+type safetyRating struct {
+	Category    string `json:"category"`
+	Probability string `json:"probability"`
+	Blocked     bool   `json:"blocked"`
+}
+
+// results returns a result for each candidate that was not blocked. A
+// candidate that was stopped by the safety filters is blocked even if it has
+// some text, since the text is incomplete.
+func (r geminiResponse) results(raw json.RawMessage) ([]llms.Result, error) {
+	if r.PromptFeedback.BlockReason != "" {
+		return nil, fmt.Errorf("%w: prompt was blocked: %s", llms.ErrSafetyBlocked, r.PromptFeedback.BlockReason)
+	}
+	if len(r.Candidates) == 0 {
+		return nil, fmt.Errorf("no candidates returned")
+	}
+
+	var results []llms.Result
+	for _, candidate := range r.Candidates {
+		reason := finishReason(candidate.FinishReason)
+		if reason == llms.FinishReasonSafety {
+			continue
+		}
+
+		result := llms.Result{
+			FinishReason: reason,
+			Raw:          raw,
+		}
+		for _, p := range candidate.Content.Parts {
+			result.Text += p.Text
+			if p.FunctionCall != nil {
+				result.FunctionCalls = append(result.FunctionCalls, *p.FunctionCall)
+			}
+		}
+		for _, rating := range candidate.SafetyRatings {
+			result.SafetyRatings = append(result.SafetyRatings, llms.SafetyRating{
+				Category:    rating.Category,
+				Probability: rating.Probability,
+				Blocked:     rating.Blocked,
+			})
+		}
+		results = append(results, result)
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("%w: response was blocked: %s", llms.ErrSafetyBlocked, r.Candidates[0].FinishReason)
+	}
+
+	results[0].Usage = llms.Usage{
+		InputTokens:  r.UsageMetadata.PromptTokenCount,
+		OutputTokens: r.UsageMetadata.CandidatesTokenCount,
+	}
+	return results, nil
+}
+
+// text returns the text of the first candidate. When the candidate was stopped
+// by the safety filters, the error wraps llms.ErrSafetyBlocked and the text is
+// the part generated before, if any.
+func (r geminiResponse) text() (string, error) {
+	if r.PromptFeedback.BlockReason != "" {
+		return "", fmt.Errorf("%w: prompt was blocked: %s", llms.ErrSafetyBlocked, r.PromptFeedback.BlockReason)
+	}
+	if len(r.Candidates) == 0 {
+		return "", fmt.Errorf("no candidates returned")
+	}
+
+	candidate := r.Candidates[0]
+	var text string
+	for _, p := range candidate.Content.Parts {
+		text += p.Text
+	}
+	if finishReason(candidate.FinishReason) == llms.FinishReasonSafety {
+		return text, fmt.Errorf("%w: response was blocked: %s", llms.ErrSafetyBlocked, candidate.FinishReason)
+	}
+	return text, nil
+}
@@ -0,0 +1,346 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vertex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/google/go-react/pkg/llms"
+)
+
+// predict generates text with the PaLM predict API.
+func (c client) predict(ctx context.Context, prompt string, params Params, a api) ([]llms.Result, error) {
+	body, err := json.Marshal(predictRequest{
+		Instances:  []map[string]string{{instanceKey(a): prompt}},
+		Parameters: newParameters(params),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url(params.Model, "predict"), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	var r response
+	raw, err := c.do(req, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(r.Predictions) == 0 {
+		return nil, fmt.Errorf("no predictions returned")
+	}
+
+	var results []llms.Result
+	for _, p := range r.Predictions {
+		if p.SafetyAttributes.Blocked && p.Content == "" {
+			continue
+		}
+		results = append(results, llms.Result{
+			Text:          p.Content,
+			FinishReason:  p.SafetyAttributes.finishReason(),
+			SafetyRatings: p.SafetyAttributes.ratings(),
+			Raw:           raw,
+		})
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("%w: response was blocked", llms.ErrSafetyBlocked)
+	}
+	results[0].Usage = r.Metadata.usage()
+	return results, nil
+}
+
+// streamPredict streams text with the PaLM serverStreamingPredict API.
+func (c client) streamPredict(ctx context.Context, prompt string, params Params, a api) (llms.Stream, error) {
+	parameters := map[string]tensor{
+		"temperature":     {FloatVal: []float64{params.Temperature}},
+		"maxOutputTokens": {IntVal: []int{params.MaxTokens}},
+		"topK":            {IntVal: []int{params.TopK}},
+		"topP":            {FloatVal: []float64{params.TopP}},
+	}
+	if len(params.StopSequences) > 0 {
+		parameters["stopSequences"] = tensor{StringVal: params.StopSequences}
+	}
+	if params.Seed != 0 {
+		parameters["seed"] = tensor{IntVal: []int{params.Seed}}
+	}
+
+	body, err := json.Marshal(streamingRequest{
+		Inputs: []tensor{{
+			StructVal: map[string]tensor{
+				instanceKey(a): {StringVal: []string{prompt}},
+			},
+		}},
+		Parameters: tensor{StructVal: parameters},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url(params.Model, "serverStreamingPredict"), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	return &stream{
+		body: resp.Body,
+		dec:  json.NewDecoder(resp.Body),
+	}, nil
+}
+
+// stream decodes the JSON array that is streamed back by the
+// serverStreamingPredict method. Each element holds the next chunk of text.
+type stream struct {
+	body    io.ReadCloser
+	dec     *json.Decoder
+	started bool
+}
+
+// Recv implements llms.Stream.
+func (s *stream) Recv() (string, error) {
+	if !s.started {
+		if _, err := s.dec.Token(); err != nil {
+			return "", fmt.Errorf("failed to decode response: %v", err)
+		}
+		s.started = true
+	}
+
+	for s.dec.More() {
+		var r streamingResponse
+		if err := s.dec.Decode(&r); err != nil {
+			return "", fmt.Errorf("failed to decode response: %v", err)
+		}
+
+		var chunk string
+		for _, o := range r.Outputs {
+			for _, v := range o.StructVal["content"].StringVal {
+				chunk += v
+			}
+		}
+		if chunk != "" {
+			return chunk, nil
+		}
+	}
+	return "", io.EOF
+}
+
+// Close implements llms.Stream.
+func (s *stream) Close() error {
+	return s.body.Close()
+}
+
+// chatPredict sends the conversation to a PaLM chat model.
+func (c client) chatPredict(ctx context.Context, messages []llms.Message, params Params) ([]llms.Result, error) {
+	var instance chatInstance
+	for _, m := range messages {
+		switch m.Role {
+		case llms.RoleSystem:
+			// The PaLM chat API only has a single context, so join all the system
+			// messages together.
+			if instance.Context != "" {
+				instance.Context += "\n\n"
+			}
+			instance.Context += m.Content
+		case llms.RoleAssistant:
+			instance.Messages = append(instance.Messages, chatMessage{Author: "bot", Content: m.Content})
+		default:
+			// The PaLM chat API has no notion of tools, so tool output is sent as
+			// if the user wrote it.
+			instance.Messages = append(instance.Messages, chatMessage{Author: "user", Content: m.Content})
+		}
+	}
+
+	body, err := json.Marshal(chatRequest{
+		Instances:  []chatInstance{instance},
+		Parameters: newParameters(params),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url(params.Model, "predict"), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	var r chatResponse
+	raw, err := c.do(req, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(r.Predictions) == 0 || len(r.Predictions[0].Candidates) == 0 {
+		return nil, fmt.Errorf("no predictions returned")
+	}
+
+	p := r.Predictions[0]
+	var results []llms.Result
+	for i, candidate := range p.Candidates {
+		// There are safety attributes for each candidate.
+		var attrs safetyAttributes
+		if i < len(p.SafetyAttributes) {
+			attrs = p.SafetyAttributes[i]
+		}
+		results = append(results, llms.Result{
+			Text:          candidate.Content,
+			FinishReason:  attrs.finishReason(),
+			SafetyRatings: attrs.ratings(),
+			Raw:           raw,
+		})
+	}
+	results[0].Usage = r.Metadata.usage()
+	return results, nil
+}
+
+// instanceKey returns the key the prompt is sent under for the given API.
+func instanceKey(a api) string {
+	if a == apiCode {
+		return "prefix"
+	}
+	return "content"
+}
+
+func newParameters(params Params) parameters {
+	return parameters{
+		Temperature:     params.Temperature,
+		MaxOutputTokens: params.MaxTokens,
+		TopK:            params.TopK,
+		TopP:            params.TopP,
+		StopSequences:   params.StopSequences,
+		CandidateCount:  params.CandidateCount,
+		Seed:            params.Seed,
+	}
+}
+
+type predictRequest struct {
+	Instances  []map[string]string `json:"instances"`
+	Parameters parameters          `json:"parameters"`
+}
+
+type response struct {
+	Predictions []struct {
+		Content          string           `json:"content"`
+		SafetyAttributes safetyAttributes `json:"safetyAttributes"`
+	} `json:"predictions"`
+	Metadata metadata `json:"metadata"`
+}
+
+type safetyAttributes struct {
+	Categories []string  `json:"categories"`
+	Scores     []float64 `json:"scores"`
+	Blocked    bool      `json:"blocked"`
+}
+
+// finishReason returns the finish reason. The predict API only reports whether
+// the response was blocked.
+func (a safetyAttributes) finishReason() llms.FinishReason {
+	if a.Blocked {
+		return llms.FinishReasonSafety
+	}
+	return llms.FinishReasonUnknown
+}
+
+func (a safetyAttributes) ratings() []llms.SafetyRating {
+	var ratings []llms.SafetyRating
+	for i, c := range a.Categories {
+		r := llms.SafetyRating{Category: c}
+		if i < len(a.Scores) {
+			r.Probability = strconv.FormatFloat(a.Scores[i], 'f', -1, 64)
+		}
+		ratings = append(ratings, r)
+	}
+	return ratings
+}
+
+type metadata struct {
+	TokenMetadata struct {
+		InputTokenCount  tokenCount `json:"inputTokenCount"`
+		OutputTokenCount tokenCount `json:"outputTokenCount"`
+	} `json:"tokenMetadata"`
+}
+
+type tokenCount struct {
+	TotalTokens int `json:"totalTokens"`
+}
+
+func (m metadata) usage() llms.Usage {
+	return llms.Usage{
+		InputTokens:  m.TokenMetadata.InputTokenCount.TotalTokens,
+		OutputTokens: m.TokenMetadata.OutputTokenCount.TotalTokens,
+	}
+}
+
+type streamingRequest struct {
+	Inputs     []tensor `json:"inputs"`
+	Parameters tensor   `json:"parameters"`
+}
+
+type streamingResponse struct {
+	Outputs []tensor `json:"outputs"`
+}
+
+// tensor is the format used by the serverStreamingPredict method for both
+// inputs and outputs.
+type tensor struct {
+	StructVal map[string]tensor `json:"structVal,omitempty"`
+	StringVal []string          `json:"stringVal,omitempty"`
+	FloatVal  []float64         `json:"floatVal,omitempty"`
+	IntVal    []int             `json:"intVal,omitempty"`
+}
+
+type parameters struct {
+	Temperature     float64  `json:"temperature"`
+	MaxOutputTokens int      `json:"maxOutputTokens"`
+	TopK            int      `json:"topK"`
+	TopP            float64  `json:"topP"`
+	StopSequences   []string `json:"stopSequences,omitempty"`
+	CandidateCount  int      `json:"candidateCount,omitempty"`
+	Seed            int      `json:"seed,omitempty"`
+}
+
+type chatRequest struct {
+	Instances  []chatInstance `json:"instances"`
+	Parameters parameters     `json:"parameters"`
+}
+
+type chatInstance struct {
+	Context  string        `json:"context,omitempty"`
+	Messages []chatMessage `json:"messages"`
+}
+
+type chatMessage struct {
+	Author  string `json:"author"`
+	Content string `json:"content"`
+}
+
+type chatResponse struct {
+	Predictions []struct {
+		Candidates       []chatMessage      `json:"candidates"`
+		SafetyAttributes []safetyAttributes `json:"safetyAttributes"`
+	} `json:"predictions"`
+	Metadata metadata `json:"metadata"`
+}
@@ -0,0 +1,117 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vertex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-react/pkg/llms"
+)
+
+// models are the limits of the known models, keyed by the model name without
+// its version.
+var models = map[string]llms.ModelInfo{
+	"gemini-1.5-pro":        {ContextWindow: 2097152, MaxOutputTokens: 8192},
+	"gemini-1.5-flash":      {ContextWindow: 1048576, MaxOutputTokens: 8192},
+	"gemini-1.0-pro-vision": {ContextWindow: 12288, MaxOutputTokens: 4096},
+	"gemini-1.0-pro":        {ContextWindow: 30720, MaxOutputTokens: 8192},
+	"gemini-pro-vision":     {ContextWindow: 12288, MaxOutputTokens: 4096},
+	"gemini-pro":            {ContextWindow: 30720, MaxOutputTokens: 8192},
+	"text-bison":            {ContextWindow: 8192, MaxOutputTokens: 1024},
+	"text-bison-32k":        {ContextWindow: 32768, MaxOutputTokens: 8192},
+	"text-unicorn":          {ContextWindow: 8192, MaxOutputTokens: 1024},
+	"chat-bison":            {ContextWindow: 8192, MaxOutputTokens: 1024},
+	"chat-bison-32k":        {ContextWindow: 32768, MaxOutputTokens: 8192},
+	"code-bison":            {ContextWindow: 6144, MaxOutputTokens: 1024},
+	"code-bison-32k":        {ContextWindow: 32768, MaxOutputTokens: 8192},
+	"codechat-bison":        {ContextWindow: 6144, MaxOutputTokens: 1024},
+	"codechat-bison-32k":    {ContextWindow: 32768, MaxOutputTokens: 8192},
+}
+
+// ModelInfo implements llms.ModelInfoLLM. Models are matched by the longest
+// known prefix of their name, so that specific versions (e.g.,
+// gemini-1.0-pro-002) are found as well.
+func (c client) ModelInfo(params Params) (llms.ModelInfo, bool) {
+	model, _, _ := strings.Cut(withDefaults(params, "text-bison@001", 64).Model, "@")
+
+	var (
+		info  llms.ModelInfo
+		found string
+	)
+	for name, i := range models {
+		if strings.HasPrefix(model, name) && len(name) > len(found) {
+			info, found = i, name
+		}
+	}
+	return info, found != ""
+}
+
+// CountTokens implements llms.TokenCounter with the countTokens API.
+func (c client) CountTokens(ctx context.Context, prompt string, params Params) (int, error) {
+	params = withDefaults(params, "text-bison@001", 64)
+	a, err := apiFor(params.Model)
+	if err != nil {
+		return 0, err
+	}
+
+	var req *http.Request
+	switch a {
+	case apiGemini:
+		// The countTokens API doesn't take safety settings.
+		params.SafetySettings = nil
+		req, err = c.newGeminiRequest(ctx, "countTokens", nil, []content{userContent(prompt)}, nil, params)
+	case apiChat:
+		req, err = c.newCountTokensRequest(ctx, params.Model, chatInstance{
+			Messages: []chatMessage{{Author: "user", Content: prompt}},
+		})
+	default:
+		req, err = c.newCountTokensRequest(ctx, params.Model, map[string]string{instanceKey(a): prompt})
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var r countTokensResponse
+	if _, err := c.do(req, &r); err != nil {
+		return 0, err
+	}
+	return r.TotalTokens, nil
+}
+
+// newCountTokensRequest returns a countTokens request for a PaLM model.
+func (c client) newCountTokensRequest(ctx context.Context, model string, instance any) (*http.Request, error) {
+	body, err := json.Marshal(map[string][]any{
+		"instances": {instance},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url(model, "countTokens"), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	return req, nil
+}
+
+type countTokensResponse struct {
+	TotalTokens             int `json:"totalTokens"`
+	TotalBillableCharacters int `json:"totalBillableCharacters"`
+}
@@ -0,0 +1,95 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vertextest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// ErrorResponse returns the error the API responds with, where status is the
+// API's status (e.g., RESOURCE_EXHAUSTED).
+func ErrorResponse(statusCode int, status, message string) Response {
+	return jsonResponse(map[string]any{
+		"error": map[string]any{
+			"code":    statusCode,
+			"message": message,
+			"status":  status,
+		},
+	}, statusCode)
+}
+
+// PredictResponse returns a response of the predict API for text and code
+// models with a prediction for each of the texts.
+func PredictResponse(texts ...string) Response {
+	type prediction struct {
+		Content string `json:"content"`
+	}
+	var predictions []prediction
+	for _, text := range texts {
+		predictions = append(predictions, prediction{Content: text})
+	}
+	return jsonResponse(map[string]any{"predictions": predictions}, http.StatusOK)
+}
+
+// GenerateContentResponse returns a response of the generateContent API with
+// a candidate for each of the texts.
+func GenerateContentResponse(texts ...string) Response {
+	return jsonResponse(generateContent(texts...), http.StatusOK)
+}
+
+// StreamGenerateContentResponse returns a response of the
+// streamGenerateContent API with an event for each of the chunks.
+func StreamGenerateContentResponse(chunks ...string) Response {
+	var b strings.Builder
+	for _, chunk := range chunks {
+		data, _ := json.Marshal(generateContent(chunk))
+		b.WriteString("data: ")
+		b.Write(data)
+		b.WriteString("\r\n\r\n")
+	}
+	return Response{
+		Header: http.Header{"Content-Type": {"text/event-stream"}},
+		Body:   b.String(),
+	}
+}
+
+// CountTokensResponse returns a response of the countTokens API.
+func CountTokensResponse(tokens int) Response {
+	return jsonResponse(map[string]any{"totalTokens": tokens}, http.StatusOK)
+}
+
+func generateContent(texts ...string) map[string]any {
+	var candidates []map[string]any
+	for _, text := range texts {
+		candidates = append(candidates, map[string]any{
+			"content": map[string]any{
+				"role":  "model",
+				"parts": []map[string]any{{"text": text}},
+			},
+			"finishReason": "STOP",
+		})
+	}
+	return map[string]any{"candidates": candidates}
+}
+
+func jsonResponse(v any, statusCode int) Response {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return Response{StatusCode: statusCode, Body: string(data)}
+}
@@ -0,0 +1,208 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vertextest has a Vertex AI emulator that can be used to test the
+// vertex package end to end without network access.
+package vertextest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-react/pkg/llms/vertex"
+)
+
+// The methods of the models that are emulated.
+const (
+	MethodPredict               = "predict"
+	MethodGenerateContent       = "generateContent"
+	MethodStreamGenerateContent = "streamGenerateContent"
+	MethodCountTokens           = "countTokens"
+)
+
+// Response is a response the Server is programmed to respond with.
+type Response struct {
+	// StatusCode defaults to http.StatusOK.
+	StatusCode int
+	// Header is added to the response (e.g., Retry-After).
+	Header http.Header
+	// Body is the body of the response. The helpers (e.g., PredictResponse)
+	// return responses with the body the API would respond with.
+	Body string
+	// Delay is how long to wait before responding, unless the request is
+	// canceled first.
+	Delay time.Duration
+}
+
+// Request is a request received by the Server.
+type Request struct {
+	Project  string
+	Location string
+	Model    string
+	Method   string
+	Header   http.Header
+	Body     json.RawMessage
+}
+
+// Server is an httptest.Server that emulates the Vertex AI endpoints of the
+// models. It responds with the responses queued for the method (see Enqueue)
+// in order, and with an error when there are none left. It is safe for
+// concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	key       string
+	responses map[string][]Response
+	requests  []Request
+}
+
+// NewServer starts and returns a new Server. The caller should call Close
+// when finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{responses: map[string][]Response{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Endpoint returns the API endpoint of the server, to be given to the vertex
+// constructors along with the Options.
+func (s *Server) Endpoint() string {
+	return strings.TrimPrefix(s.URL, "http://")
+}
+
+// Options returns the options the vertex constructors need to send their
+// requests to the server.
+func (s *Server) Options() []vertex.Option {
+	return []vertex.Option{
+		vertex.WithPlainHTTP(),
+		vertex.WithHTTPClient(s.Client()),
+	}
+}
+
+// RequireKey makes the server respond with UNAUTHENTICATED to the requests
+// without the key as their bearer token.
+func (s *Server) RequireKey(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.key = key
+}
+
+// Enqueue queues the responses to the next requests for the method.
+func (s *Server) Enqueue(method string, responses ...Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses[method] = append(s.responses[method], responses...)
+}
+
+// Requests returns the requests received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	req, err := parseRequest(r)
+	if err != nil {
+		writeResponse(w, ErrorResponse(http.StatusNotFound, "NOT_FOUND", err.Error()))
+		return
+	}
+
+	resp, ok := s.next(req)
+	if !ok {
+		writeResponse(w, ErrorResponse(http.StatusNotImplemented, "UNIMPLEMENTED", fmt.Sprintf("vertextest: no response queued for %s", req.Method)))
+		return
+	}
+
+	if resp.Delay > 0 {
+		t := time.NewTimer(resp.Delay)
+		defer t.Stop()
+		select {
+		case <-t.C:
+		case <-r.Context().Done():
+			return
+		}
+	}
+	writeResponse(w, resp)
+}
+
+// next records the request and returns the response to it.
+func (s *Server) next(req Request) (Response, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+
+	if s.key != "" && req.Header.Get("Authorization") != "Bearer "+s.key {
+		return ErrorResponse(http.StatusUnauthorized, "UNAUTHENTICATED", "Request had invalid authentication credentials."), true
+	}
+
+	responses := s.responses[req.Method]
+	if len(responses) == 0 {
+		return Response{}, false
+	}
+	s.responses[req.Method] = responses[1:]
+	return responses[0], true
+}
+
+// parseRequest parses a request to
+// /v1/projects/{project}/locations/{location}/publishers/google/models/{model}:{method}.
+func parseRequest(r *http.Request) (Request, error) {
+	segments := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if len(segments) != 9 ||
+		segments[0] != "v1" ||
+		segments[1] != "projects" ||
+		segments[3] != "locations" ||
+		segments[5] != "publishers" ||
+		segments[7] != "models" {
+		return Request{}, fmt.Errorf("vertextest: unknown path %q", r.URL.Path)
+	}
+	model, method, ok := strings.Cut(segments[8], ":")
+	if !ok {
+		return Request{}, fmt.Errorf("vertextest: no method in path %q", r.URL.Path)
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return Request{}, fmt.Errorf("vertextest: failed to read request: %v", err)
+	}
+	return Request{
+		Project:  segments[2],
+		Location: segments[4],
+		Model:    model,
+		Method:   method,
+		Header:   r.Header.Clone(),
+		Body:     body,
+	}, nil
+}
+
+func writeResponse(w http.ResponseWriter, resp Response) {
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	if resp.StatusCode == 0 {
+		resp.StatusCode = http.StatusOK
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.WriteString(w, resp.Body)
+}
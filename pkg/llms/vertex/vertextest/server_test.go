@@ -0,0 +1,227 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vertextest_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-react/pkg/llms"
+	"github.com/google/go-react/pkg/llms/vertex"
+	"github.com/google/go-react/pkg/llms/vertex/vertextest"
+)
+
+func newServer(t *testing.T) (*vertextest.Server, llms.LLM[vertex.Params]) {
+	t.Helper()
+	srv := vertextest.NewServer()
+	t.Cleanup(srv.Close)
+	return srv, vertex.NewWithKey("some-key", srv.Endpoint(), "some-project", srv.Options()...)
+}
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		model    string
+		method   string
+		response vertextest.Response
+		expected string
+	}{
+		{
+			name:     "predict",
+			model:    "text-bison@002",
+			method:   vertextest.MethodPredict,
+			response: vertextest.PredictResponse("some-response"),
+			expected: "some-response",
+		},
+		{
+			name:     "generateContent",
+			model:    "gemini-1.0-pro",
+			method:   vertextest.MethodGenerateContent,
+			response: vertextest.GenerateContentResponse("some-response"),
+			expected: "some-response",
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv, llm := newServer(t)
+			srv.RequireKey("some-key")
+			srv.Enqueue(tc.method, tc.response)
+
+			resp, err := llm.Generate(context.Background(), "some-prompt", vertex.Params{Model: tc.model})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual, expected := resp, tc.expected; actual != expected {
+				t.Fatalf("expected %q, got %q", expected, actual)
+			}
+
+			reqs := srv.Requests()
+			if actual, expected := len(reqs), 1; actual != expected {
+				t.Fatalf("expected %d, got %d", expected, actual)
+			}
+			req := reqs[0]
+			for _, c := range []struct{ actual, expected string }{
+				{req.Project, "some-project"},
+				{req.Location, vertex.DefaultLocation},
+				{req.Model, tc.model},
+				{req.Method, tc.method},
+				{req.Header.Get("Authorization"), "Bearer some-key"},
+			} {
+				if c.actual != c.expected {
+					t.Fatalf("expected %q, got %q", c.expected, c.actual)
+				}
+			}
+			if !strings.Contains(string(req.Body), "some-prompt") {
+				t.Fatalf("expected the prompt in the body, got %s", req.Body)
+			}
+		})
+	}
+}
+
+func TestServer_stream(t *testing.T) {
+	t.Parallel()
+
+	srv, llm := newServer(t)
+	srv.Enqueue(vertextest.MethodStreamGenerateContent, vertextest.StreamGenerateContentResponse("some-", "response"))
+
+	s, err := llms.GenerateStream(context.Background(), llm, "some-prompt", vertex.Params{Model: "gemini-1.0-pro"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	var chunks []string
+	for {
+		chunk, err := s.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		chunks = append(chunks, chunk)
+	}
+	if actual, expected := strings.Join(chunks, "|"), "some-|response"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestServer_countTokens(t *testing.T) {
+	t.Parallel()
+
+	srv, llm := newServer(t)
+	srv.Enqueue(vertextest.MethodCountTokens, vertextest.CountTokensResponse(7))
+
+	n, err := llms.CountTokens(context.Background(), llm, "some-prompt", vertex.Params{Model: "gemini-1.0-pro"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := n, 7; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+}
+
+func TestServer_errors(t *testing.T) {
+	t.Parallel()
+
+	rateLimited := vertextest.ErrorResponse(http.StatusTooManyRequests, "RESOURCE_EXHAUSTED", "Quota exceeded.")
+	rateLimited.Header = http.Header{"Retry-After": {"2"}}
+
+	testCases := []struct {
+		name       string
+		setup      func(*vertextest.Server)
+		expected   error
+		retryAfter time.Duration
+	}{
+		{
+			name: "rate limited",
+			setup: func(srv *vertextest.Server) {
+				srv.Enqueue(vertextest.MethodPredict, rateLimited)
+			},
+			expected:   llms.ErrRateLimited,
+			retryAfter: 2 * time.Second,
+		},
+		{
+			name: "context length",
+			setup: func(srv *vertextest.Server) {
+				srv.Enqueue(vertextest.MethodPredict, vertextest.ErrorResponse(http.StatusBadRequest, "INVALID_ARGUMENT", "The input token count exceeds the maximum."))
+			},
+			expected: llms.ErrContextLength,
+		},
+		{
+			name: "wrong key",
+			setup: func(srv *vertextest.Server) {
+				srv.RequireKey("other-key")
+				srv.Enqueue(vertextest.MethodPredict, vertextest.PredictResponse("some-response"))
+			},
+			expected: llms.ErrAuth,
+		},
+		{
+			name:     "no response queued",
+			expected: llms.ErrTransient,
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv, llm := newServer(t)
+			if tc.setup != nil {
+				tc.setup(srv)
+			}
+
+			_, err := llm.Generate(context.Background(), "some-prompt", vertex.Params{Model: "text-bison"})
+			if actual, expected := err, tc.expected; !errors.Is(actual, expected) {
+				t.Fatalf("expected %v, got %v", expected, actual)
+			}
+			var apiErr *llms.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected an APIError, got %v", err)
+			}
+			if actual, expected := apiErr.RetryAfter, tc.retryAfter; actual != expected {
+				t.Fatalf("expected %v, got %v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestServer_delay(t *testing.T) {
+	t.Parallel()
+
+	srv, llm := newServer(t)
+	resp := vertextest.PredictResponse("some-response")
+	resp.Delay = time.Minute
+	srv.Enqueue(vertextest.MethodPredict, resp)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := llm.Generate(ctx, "some-prompt", vertex.Params{Model: "text-bison"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+}
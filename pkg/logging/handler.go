@@ -0,0 +1,172 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"unicode/utf8"
+)
+
+// Redacted replaces the text that is redacted.
+const Redacted = "[REDACTED]"
+
+// Redactor returns the given text with any sensitive information replaced.
+type Redactor func(string) string
+
+var (
+	apiKeyPattern = regexp.MustCompile(
+		// Google API keys, OAuth access tokens, OpenAI style keys and bearer
+		// tokens in headers.
+		`AIza[0-9A-Za-z_\-]{35}|ya29\.[0-9A-Za-z_\-.]+|sk-[0-9A-Za-z_\-]{20,}|(?i:bearer)\s+[0-9A-Za-z_\-.=]+`,
+	)
+	emailPattern = regexp.MustCompile(`[0-9A-Za-z._%+\-]+@[0-9A-Za-z.\-]+\.[A-Za-z]{2,}`)
+)
+
+// RedactPattern returns a Redactor that replaces each match of the pattern
+// with Redacted.
+func RedactPattern(pattern *regexp.Regexp) Redactor {
+	return func(s string) string {
+		return pattern.ReplaceAllString(s, Redacted)
+	}
+}
+
+// RedactAPIKeys returns a Redactor for API keys and access tokens (e.g.,
+// Google API keys and bearer tokens).
+func RedactAPIKeys() Redactor {
+	return RedactPattern(apiKeyPattern)
+}
+
+// RedactEmails returns a Redactor for email addresses.
+func RedactEmails() Redactor {
+	return RedactPattern(emailPattern)
+}
+
+// Option is an option for NewHandler.
+type Option func(*handler)
+
+// WithRedactors adds Redactors that are applied, in order, to the message and
+// string attributes.
+func WithRedactors(redactors ...Redactor) Option {
+	return func(h *handler) {
+		h.redactors = append(h.redactors, redactors...)
+	}
+}
+
+// WithMaxLength truncates the string attributes (e.g., long prompts) to the
+// given number of bytes. Zero means they are not truncated, which is the
+// default.
+func WithMaxLength(n int) Option {
+	return func(h *handler) {
+		h.maxLength = n
+	}
+}
+
+// NewHandler returns an slog.Handler that redacts and truncates the message
+// and the string and error attributes, including the ones in groups, before
+// passing the record to the given handler. Other attributes (e.g., structs)
+// are passed as is.
+func NewHandler(next slog.Handler, opts ...Option) slog.Handler {
+	h := &handler{next: next}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+type handler struct {
+	next      slog.Handler
+	redactors []Redactor
+	maxLength int
+}
+
+// Enabled implements slog.Handler.
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler.
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, h.redact(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(h.attr(a))
+		return true
+	})
+	return h.next.Handle(ctx, out)
+}
+
+// WithAttrs implements slog.Handler.
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clean := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		clean = append(clean, h.attr(a))
+	}
+	return h.with(h.next.WithAttrs(clean))
+}
+
+// WithGroup implements slog.Handler.
+func (h *handler) WithGroup(name string) slog.Handler {
+	return h.with(h.next.WithGroup(name))
+}
+
+func (h *handler) with(next slog.Handler) *handler {
+	return &handler{
+		next:      next,
+		redactors: h.redactors,
+		maxLength: h.maxLength,
+	}
+}
+
+func (h *handler) attr(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, h.truncate(h.redact(v.String())))
+	case slog.KindGroup:
+		group := v.Group()
+		clean := make([]any, 0, len(group))
+		for _, ga := range group {
+			clean = append(clean, h.attr(ga))
+		}
+		return slog.Group(a.Key, clean...)
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return slog.String(a.Key, h.truncate(h.redact(err.Error())))
+		}
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
+
+func (h *handler) redact(s string) string {
+	for _, r := range h.redactors {
+		s = r(s)
+	}
+	return s
+}
+
+// truncate cuts the string to the max length without splitting a character,
+// noting how much was cut.
+func (h *handler) truncate(s string) string {
+	if h.maxLength <= 0 || len(s) <= h.maxLength {
+		return s
+	}
+	n := h.maxLength
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return fmt.Sprintf("%s...(%d bytes truncated)", s[:n], len(s)-n)
+}
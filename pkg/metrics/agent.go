@@ -0,0 +1,82 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"context"
+
+	"github.com/google/go-react/pkg/agents"
+	"github.com/google/go-react/pkg/chains"
+	"github.com/google/go-react/pkg/predictors"
+	"github.com/google/go-react/pkg/tools"
+)
+
+// NewAgent returns a Chain that runs the agent and records the retries it
+// makes. Use NewAgentPredictor and NewTools when creating the agent to record
+// its iterations and tool calls.
+func NewAgent[TOut any](agent agents.Agent[TOut], r Recorder) chains.Chain[string, TOut] {
+	return chains.ChainFunc[string, TOut](func(ctx context.Context, goal string) (TOut, error) {
+		return agent.Run(withRetries(ctx, r), goal)
+	})
+}
+
+// NewAgentPredictor returns a Predictor for agents.NewAgent that records an
+// iteration of the named agent for each reasoning the LLM responds with, and
+// the responses that fail to parse.
+func NewAgentPredictor[TOut any](
+	p predictors.Predictor[agents.PromptData[TOut], agents.Reasoning[TOut]],
+	r Recorder,
+	name string,
+) predictors.Predictor[agents.PromptData[TOut], agents.Reasoning[TOut]] {
+	return agentPredictor[TOut]{
+		p:    p,
+		r:    r,
+		name: name,
+	}
+}
+
+type agentPredictor[TOut any] struct {
+	p    predictors.Predictor[agents.PromptData[TOut], agents.Reasoning[TOut]]
+	r    Recorder
+	name string
+}
+
+// Predict implements predictors.Predictor.
+func (a agentPredictor[TOut]) Predict(ctx context.Context, req agents.PromptData[TOut]) (agents.Reasoning[TOut], error) {
+	resp, err := a.p.Predict(ctx, req)
+	switch {
+	case err == nil:
+		a.r.RecordAgentIteration(a.name)
+	case isParseFailure(err):
+		a.r.RecordParseFailure(a.name)
+	}
+	return resp, err
+}
+
+// NewTools returns the tools with their Run wrapped to record each call.
+func NewTools(toolSet []tools.Tool, r Recorder) []tools.Tool {
+	recorded := make([]tools.Tool, 0, len(toolSet))
+	for _, t := range toolSet {
+		t := t
+		run := t.Run
+		t.Run = func(ctx context.Context, input string) (any, error) {
+			out, err := run(ctx, input)
+			r.RecordToolInvocation(t.Name, err)
+			return out, err
+		}
+		recorded = append(recorded, t)
+	}
+	return recorded
+}
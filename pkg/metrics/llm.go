@@ -0,0 +1,122 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/google/go-react/pkg/llms"
+)
+
+// NewLLM returns an LLM that records the latency and token usage of each
+// request. The model is read from the params with the given function, which
+// may be nil. Tokens are only recorded when the LLM implements
+// llms.MetadataLLM.
+func NewLLM[TParams any](llm llms.LLM[TParams], r Recorder, model func(TParams) string) llms.LLM[TParams] {
+	return metricsLLM[TParams]{
+		llm:   llm,
+		r:     r,
+		model: model,
+	}
+}
+
+type metricsLLM[TParams any] struct {
+	llm   llms.LLM[TParams]
+	r     Recorder
+	model func(TParams) string
+}
+
+// Generate implements llms.LLM.
+func (l metricsLLM[TParams]) Generate(ctx context.Context, prompt string, params TParams) (string, error) {
+	result, err := l.GenerateWithMetadata(ctx, prompt, params)
+	if err != nil {
+		return "", err
+	}
+	return result.Text, nil
+}
+
+// GenerateWithMetadata implements llms.MetadataLLM.
+func (l metricsLLM[TParams]) GenerateWithMetadata(ctx context.Context, prompt string, params TParams) (llms.Result, error) {
+	model := l.modelName(params)
+	start := time.Now()
+	result, err := llms.GenerateWithMetadata(ctx, l.llm, prompt, params)
+	l.r.RecordLLMRequest(model, time.Since(start), err)
+	if err != nil {
+		return llms.Result{}, err
+	}
+	if result.Usage != (llms.Usage{}) {
+		l.r.RecordTokens(model, result.Usage)
+	}
+	return result, nil
+}
+
+// GenerateStream implements llms.StreamLLM. The request is recorded once the
+// stream has been fully read or closed.
+func (l metricsLLM[TParams]) GenerateStream(ctx context.Context, prompt string, params TParams) (llms.Stream, error) {
+	model := l.modelName(params)
+	start := time.Now()
+	s, err := llms.GenerateStream(ctx, l.llm, prompt, params)
+	if err != nil {
+		l.r.RecordLLMRequest(model, time.Since(start), err)
+		return nil, err
+	}
+	return &metricsStream{
+		s: s,
+		record: func(err error) {
+			l.r.RecordLLMRequest(model, time.Since(start), err)
+		},
+	}, nil
+}
+
+func (l metricsLLM[TParams]) modelName(params TParams) string {
+	if l.model == nil {
+		return ""
+	}
+	return l.model(params)
+}
+
+type metricsStream struct {
+	s        llms.Stream
+	record   func(err error)
+	recorded bool
+}
+
+// Recv implements llms.Stream.
+func (s *metricsStream) Recv() (string, error) {
+	chunk, err := s.s.Recv()
+	if errors.Is(err, io.EOF) {
+		s.end(nil)
+	} else if err != nil {
+		s.end(err)
+	}
+	return chunk, err
+}
+
+// Close implements llms.Stream.
+func (s *metricsStream) Close() error {
+	s.end(nil)
+	return s.s.Close()
+}
+
+func (s *metricsStream) end(err error) {
+	if s.recorded {
+		return
+	}
+	s.recorded = true
+	s.record(err)
+}
@@ -0,0 +1,76 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/go-react/pkg/llms"
+	"github.com/google/go-react/pkg/predictors"
+)
+
+// Recorder records the metrics from the wrappers in this package. The errors
+// are given as is so that they can be classified with ErrorClass.
+type Recorder interface {
+	// RecordLLMRequest records a request to the model and how long it took.
+	RecordLLMRequest(model string, latency time.Duration, err error)
+	// RecordTokens records the tokens used by a request to the model.
+	RecordTokens(model string, usage llms.Usage)
+	// RecordParseFailure records a response that the named predictor failed to
+	// parse.
+	RecordParseFailure(predictor string)
+	// RecordRetry records a prediction that is retried because of err.
+	RecordRetry(err error)
+	// RecordToolInvocation records a call to the tool.
+	RecordToolInvocation(tool string, err error)
+	// RecordAgentIteration records an iteration of the named agent.
+	RecordAgentIteration(agent string)
+}
+
+// The classes returned by ErrorClass.
+const (
+	ClassNone           = ""
+	ClassParse          = "parse"
+	ClassTruncated      = "truncated"
+	ClassRateLimited    = llms.ClassRateLimited
+	ClassTransient      = llms.ClassTransient
+	ClassInvalidRequest = llms.ClassInvalidRequest
+	ClassAuth           = llms.ClassAuth
+	ClassContextLength  = llms.ClassContextLength
+	ClassSafetyBlocked  = llms.ClassSafetyBlocked
+	ClassBudgetExceeded = llms.ClassBudgetExceeded
+	ClassCanceled       = llms.ClassCanceled
+	ClassDeadline       = llms.ClassDeadline
+	ClassOther          = "other"
+)
+
+// ErrorClass returns a short class for the error that is suitable as a metric
+// label. It is ClassNone for a nil error and ClassOther when the error is not
+// one of the llms or predictors errors.
+func ErrorClass(err error) string {
+	switch {
+	case err == nil:
+		return ClassNone
+	case errors.Is(err, predictors.ErrParse):
+		return ClassParse
+	case errors.Is(err, predictors.ErrTruncated):
+		return ClassTruncated
+	}
+	if class := llms.ErrorClass(err); class != "" {
+		return class
+	}
+	return ClassOther
+}
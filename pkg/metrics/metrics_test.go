@@ -0,0 +1,191 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/google/go-react/pkg/agents"
+	"github.com/google/go-react/pkg/llms"
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+	"github.com/google/go-react/pkg/metrics"
+	"github.com/google/go-react/pkg/metrics/prometheus"
+	"github.com/google/go-react/pkg/predictors"
+	predictorstesting "github.com/google/go-react/pkg/predictors/testing"
+	"github.com/google/go-react/pkg/tools"
+)
+
+type params struct {
+	Model string
+}
+
+func newRecorder(t *testing.T) (metrics.Recorder, *prom.Registry) {
+	t.Helper()
+	reg := prom.NewRegistry()
+	r, err := prometheus.New(reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, reg
+}
+
+func TestErrorClass(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		err      error
+		expected string
+	}{
+		{err: nil, expected: metrics.ClassNone},
+		{err: fmt.Errorf("%w: some-error", predictors.ErrParse), expected: metrics.ClassParse},
+		{err: fmt.Errorf("%w: some-error", predictors.ErrTruncated), expected: metrics.ClassTruncated},
+		{err: fmt.Errorf("%w: %w", predictors.ErrLLM, llms.ErrRateLimited), expected: metrics.ClassRateLimited},
+		{err: &llms.APIError{StatusCode: 503, Err: llms.ErrTransient}, expected: metrics.ClassTransient},
+		{err: llms.ErrInvalidRequest, expected: metrics.ClassInvalidRequest},
+		{err: llms.ErrAuth, expected: metrics.ClassAuth},
+		{err: llms.ErrContextLength, expected: metrics.ClassContextLength},
+		{err: llms.ErrSafetyBlocked, expected: metrics.ClassSafetyBlocked},
+		{err: llms.ErrBudgetExceeded, expected: metrics.ClassBudgetExceeded},
+		{err: context.Canceled, expected: metrics.ClassCanceled},
+		{err: context.DeadlineExceeded, expected: metrics.ClassDeadline},
+		{err: errors.New("some-error"), expected: metrics.ClassOther},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(fmt.Sprint(tc.err), func(t *testing.T) {
+			t.Parallel()
+			if actual, expected := metrics.ErrorClass(tc.err), tc.expected; actual != expected {
+				t.Fatalf("expected %q, got %q", expected, actual)
+			}
+		})
+	}
+}
+
+func TestLLM(t *testing.T) {
+	t.Parallel()
+
+	r, reg := newRecorder(t)
+	fake := &llmstesting.Fake[params]{
+		Results: map[string]llms.Result{
+			"some-prompt": {Text: "some-output", Usage: llms.Usage{InputTokens: 3, OutputTokens: 5}},
+		},
+		Errs: map[string]error{"bad-prompt": llms.ErrRateLimited},
+	}
+	llm := metrics.NewLLM[params](fake, r, func(p params) string { return p.Model })
+
+	if _, err := llm.Generate(context.Background(), "some-prompt", params{Model: "some-model"}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := llm.Generate(context.Background(), "bad-prompt", params{Model: "some-model"}); !errors.Is(err, llms.ErrRateLimited) {
+		t.Fatalf("expected %v, got %v", llms.ErrRateLimited, err)
+	}
+
+	expected := `
+# HELP go_react_llm_tokens_total Tokens used by the requests to the LLM.
+# TYPE go_react_llm_tokens_total counter
+go_react_llm_tokens_total{model="some-model",type="input"} 3
+go_react_llm_tokens_total{model="some-model",type="output"} 5
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected), "go_react_llm_tokens_total"); err != nil {
+		t.Fatal(err)
+	}
+
+	// One series for each status.
+	if actual, err := testutil.GatherAndCount(reg, "go_react_llm_request_duration_seconds"); err != nil {
+		t.Fatal(err)
+	} else if expected := 2; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+}
+
+func TestPredictor(t *testing.T) {
+	t.Parallel()
+
+	r, reg := newRecorder(t)
+	fake := &predictorstesting.Fake[string, string]{
+		Err: fmt.Errorf("%w: some-error", predictors.ErrParse),
+	}
+	p := metrics.NewPredictor(predictors.NewRetrier(metrics.NewPredictor[string, string](fake, r, "some-predictor")), r, "retrier")
+
+	// The retry handler of the caller is still called.
+	var retries int
+	ctx := predictors.WithRetryHandler(context.Background(), func(err error) {
+		retries++
+	})
+	if _, err := p.Predict(ctx, "some-req"); !errors.Is(err, predictors.ErrParse) {
+		t.Fatalf("expected %v, got %v", predictors.ErrParse, err)
+	}
+	if actual, expected := retries, 2; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+
+	expected := `
+# HELP go_react_parse_failures_total Responses from the LLM that failed to parse.
+# TYPE go_react_parse_failures_total counter
+go_react_parse_failures_total{predictor="retrier"} 1
+go_react_parse_failures_total{predictor="some-predictor"} 3
+# HELP go_react_retries_total Predictions that were retried, by error class.
+# TYPE go_react_retries_total counter
+go_react_retries_total{class="parse"} 2
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected), "go_react_parse_failures_total", "go_react_retries_total"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAgent(t *testing.T) {
+	t.Parallel()
+
+	r, reg := newRecorder(t)
+	predictor := metrics.NewAgentPredictor[string](&predictorstesting.Fake[agents.PromptData[string], agents.Reasoning[string]]{
+		Resps: []agents.Reasoning[string]{
+			{Thought: "some-thought", Action: "some-tool", Input: "some-input"},
+			{Thought: "some-thought", FinalAnswer: "some-answer"},
+		},
+	}, r, "some-agent")
+	toolSet := metrics.NewTools([]tools.Tool{{
+		Name:        "some-tool",
+		Description: "some-description",
+		Run: func(ctx context.Context, input string) (any, error) {
+			return nil, errors.New("some-error")
+		},
+	}}, r)
+	agent := metrics.NewAgent(agents.NewAgent(predictor, toolSet...), r)
+
+	if _, err := agent.Run(context.Background(), "some-goal"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := `
+# HELP go_react_agent_iterations_total Iterations of the agents' ReAct loop.
+# TYPE go_react_agent_iterations_total counter
+go_react_agent_iterations_total{agent="some-agent"} 2
+# HELP go_react_tool_invocations_total Calls to the agents' tools.
+# TYPE go_react_tool_invocations_total counter
+go_react_tool_invocations_total{status="other",tool="some-tool"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected), "go_react_agent_iterations_total", "go_react_tool_invocations_total"); err != nil {
+		t.Fatal(err)
+	}
+}
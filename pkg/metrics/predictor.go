@@ -0,0 +1,56 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/go-react/pkg/predictors"
+)
+
+// NewPredictor returns a Predictor that records the responses the given
+// Predictor fails to parse, along with the retries made by any
+// predictors.NewRetrier it wraps.
+func NewPredictor[TReq, TResp any](p predictors.Predictor[TReq, TResp], r Recorder, name string) predictors.Predictor[TReq, TResp] {
+	return metricsPredictor[TReq, TResp]{
+		p:    p,
+		r:    r,
+		name: name,
+	}
+}
+
+type metricsPredictor[TReq, TResp any] struct {
+	p    predictors.Predictor[TReq, TResp]
+	r    Recorder
+	name string
+}
+
+// Predict implements predictors.Predictor.
+func (m metricsPredictor[TReq, TResp]) Predict(ctx context.Context, req TReq) (TResp, error) {
+	resp, err := m.p.Predict(withRetries(ctx, m.r), req)
+	if isParseFailure(err) {
+		m.r.RecordParseFailure(m.name)
+	}
+	return resp, err
+}
+
+func withRetries(ctx context.Context, r Recorder) context.Context {
+	return predictors.WithRetryHandler(ctx, r.RecordRetry)
+}
+
+func isParseFailure(err error) bool {
+	return errors.Is(err, predictors.ErrParse) || errors.Is(err, predictors.ErrTruncated)
+}
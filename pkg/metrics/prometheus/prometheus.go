@@ -0,0 +1,172 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package prometheus has a metrics.Recorder that exposes the metrics to
+// Prometheus.
+package prometheus
+
+import (
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+
+	"github.com/google/go-react/pkg/llms"
+	"github.com/google/go-react/pkg/metrics"
+)
+
+// DefaultNamespace is the namespace of the metrics unless WithNamespace is
+// given.
+const DefaultNamespace = "go_react"
+
+// statusOK is the status label for calls that did not fail.
+const statusOK = "ok"
+
+// Option is an option for New.
+type Option func(*config)
+
+type config struct {
+	namespace string
+	buckets   []float64
+}
+
+// WithNamespace sets the namespace the metric names are prefixed with.
+func WithNamespace(namespace string) Option {
+	return func(c *config) {
+		c.namespace = namespace
+	}
+}
+
+// WithBuckets sets the buckets, in seconds, of the LLM latency histogram. It
+// defaults to prometheus.DefBuckets.
+func WithBuckets(buckets []float64) Option {
+	return func(c *config) {
+		c.buckets = buckets
+	}
+}
+
+type recorder struct {
+	llmLatency      *prom.HistogramVec
+	tokens          *prom.CounterVec
+	parseFailures   *prom.CounterVec
+	retries         *prom.CounterVec
+	toolInvocations *prom.CounterVec
+	agentIterations *prom.CounterVec
+}
+
+// New returns a metrics.Recorder with its metrics registered with reg. It
+// records:
+//
+//   - llm_request_duration_seconds: a histogram by model and status.
+//   - llm_tokens_total: a counter by model and type (input or output).
+//   - parse_failures_total: a counter by predictor.
+//   - retries_total: a counter by class (see metrics.ErrorClass).
+//   - tool_invocations_total: a counter by tool and status.
+//   - agent_iterations_total: a counter by agent.
+//
+// The status is "ok" or the error's class.
+func New(reg prom.Registerer, opts ...Option) (metrics.Recorder, error) {
+	c := config{
+		namespace: DefaultNamespace,
+		buckets:   prom.DefBuckets,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	r := recorder{
+		llmLatency: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: c.namespace,
+			Name:      "llm_request_duration_seconds",
+			Help:      "Latency of the requests to the LLM.",
+			Buckets:   c.buckets,
+		}, []string{"model", "status"}),
+		tokens: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: c.namespace,
+			Name:      "llm_tokens_total",
+			Help:      "Tokens used by the requests to the LLM.",
+		}, []string{"model", "type"}),
+		parseFailures: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: c.namespace,
+			Name:      "parse_failures_total",
+			Help:      "Responses from the LLM that failed to parse.",
+		}, []string{"predictor"}),
+		retries: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: c.namespace,
+			Name:      "retries_total",
+			Help:      "Predictions that were retried, by error class.",
+		}, []string{"class"}),
+		toolInvocations: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: c.namespace,
+			Name:      "tool_invocations_total",
+			Help:      "Calls to the agents' tools.",
+		}, []string{"tool", "status"}),
+		agentIterations: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: c.namespace,
+			Name:      "agent_iterations_total",
+			Help:      "Iterations of the agents' ReAct loop.",
+		}, []string{"agent"}),
+	}
+
+	for _, c := range []prom.Collector{
+		r.llmLatency,
+		r.tokens,
+		r.parseFailures,
+		r.retries,
+		r.toolInvocations,
+		r.agentIterations,
+	} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// RecordLLMRequest implements metrics.Recorder.
+func (r recorder) RecordLLMRequest(model string, latency time.Duration, err error) {
+	r.llmLatency.WithLabelValues(model, status(err)).Observe(latency.Seconds())
+}
+
+// RecordTokens implements metrics.Recorder.
+func (r recorder) RecordTokens(model string, usage llms.Usage) {
+	r.tokens.WithLabelValues(model, "input").Add(float64(usage.InputTokens))
+	r.tokens.WithLabelValues(model, "output").Add(float64(usage.OutputTokens))
+}
+
+// RecordParseFailure implements metrics.Recorder.
+func (r recorder) RecordParseFailure(predictor string) {
+	r.parseFailures.WithLabelValues(predictor).Inc()
+}
+
+// RecordRetry implements metrics.Recorder.
+func (r recorder) RecordRetry(err error) {
+	r.retries.WithLabelValues(metrics.ErrorClass(err)).Inc()
+}
+
+// RecordToolInvocation implements metrics.Recorder.
+func (r recorder) RecordToolInvocation(tool string, err error) {
+	r.toolInvocations.WithLabelValues(tool, status(err)).Inc()
+}
+
+// RecordAgentIteration implements metrics.Recorder.
+func (r recorder) RecordAgentIteration(agent string) {
+	r.agentIterations.WithLabelValues(agent).Inc()
+}
+
+func status(err error) string {
+	if err == nil {
+		return statusOK
+	}
+	return metrics.ErrorClass(err)
+}
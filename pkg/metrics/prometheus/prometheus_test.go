@@ -0,0 +1,93 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/google/go-react/pkg/llms"
+	"github.com/google/go-react/pkg/metrics/prometheus"
+)
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	reg := prom.NewRegistry()
+	r, err := prometheus.New(reg, prometheus.WithNamespace("some_app"), prometheus.WithBuckets([]float64{1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.RecordLLMRequest("some-model", 500*time.Millisecond, nil)
+	r.RecordLLMRequest("some-model", 2*time.Second, llms.ErrTransient)
+	r.RecordTokens("some-model", llms.Usage{InputTokens: 3, OutputTokens: 5})
+	r.RecordParseFailure("some-predictor")
+	r.RecordRetry(llms.ErrRateLimited)
+	r.RecordToolInvocation("some-tool", nil)
+	r.RecordAgentIteration("some-agent")
+
+	// Scrape the registry the way Prometheus would.
+	server := httptest.NewServer(promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	t.Cleanup(server.Close)
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		`some_app_llm_request_duration_seconds_bucket{model="some-model",status="ok",le="1"} 1`,
+		`some_app_llm_request_duration_seconds_bucket{model="some-model",status="transient",le="1"} 0`,
+		`some_app_llm_tokens_total{model="some-model",type="input"} 3`,
+		`some_app_llm_tokens_total{model="some-model",type="output"} 5`,
+		`some_app_parse_failures_total{predictor="some-predictor"} 1`,
+		`some_app_retries_total{class="rate_limited"} 1`,
+		`some_app_tool_invocations_total{status="ok",tool="some-tool"} 1`,
+		`some_app_agent_iterations_total{agent="some-agent"} 1`,
+	} {
+		if !strings.Contains(string(body), expected) {
+			t.Fatalf("expected %q in:\n%s", expected, body)
+		}
+	}
+}
+
+func TestRecorder_alreadyRegistered(t *testing.T) {
+	t.Parallel()
+
+	reg := prom.NewRegistry()
+	if _, err := prometheus.New(reg); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := prometheus.New(reg)
+	var are prom.AlreadyRegisteredError
+	if actual, expected := errors.As(err, &are), true; actual != expected {
+		t.Fatalf("expected %v, got %v (%v)", expected, actual, err)
+	}
+}
@@ -25,12 +25,17 @@ import (
 )
 
 var (
-	// ErrLLM is returned when prompting the LLM fails. This error will be retried
-	// on.
+	// ErrLLM is returned when prompting the LLM fails. It wraps the LLM's error,
+	// so it can be classified with the llms errors (e.g., llms.ErrRateLimited).
+	// This error will be retried on when llms.IsRetryable says so.
 	ErrLLM = errors.New("failed to obtain response from LLM")
 	// ErrParse is returned when the response from the LLM fails to parse. This
 	// error will be retried on.
 	ErrParse = errors.New("failed to parse response")
+	// ErrTruncated is returned instead of ErrParse when the response fails to
+	// parse because the LLM hit the maximum number of tokens. This error will
+	// not be retried on as the same params would be truncated again.
+	ErrTruncated = errors.New("response was truncated")
 )
 
 // Predictor has a Predict method that will be used to predict responses from the LLM.
@@ -68,13 +73,103 @@ func (p predictor[TReq, TResp, TLLMParams]) Predict(ctx context.Context, req TRe
 		return empty, fmt.Errorf("%w: %v", prompters.ErrHydrate, err)
 	}
 
-	llmOutput, err := p.model.Generate(ctx, prompt, params)
+	llmOutput, err := generate(ctx, p.model, prompt, params)
 	if err != nil {
-		return empty, fmt.Errorf("%w: %v", ErrLLM, err)
+		return empty, fmt.Errorf("%w: %w", ErrLLM, err)
 	}
 
-	result, err := p.parser.Parse(llmOutput)
+	result, err := p.parser.Parse(llmOutput.Text)
 	if err != nil {
+		if llmOutput.FinishReason == llms.FinishReasonMaxTokens {
+			return empty, fmt.Errorf("%w: %v", ErrTruncated, err)
+		}
+		return empty, fmt.Errorf("%w: %v", ErrParse, err)
+	}
+
+	return result, nil
+}
+
+type chatPredictor[TReq, TResp, TLLMParams any] struct {
+	model    llms.ChatLLM[TLLMParams]
+	prompter prompters.ChatPrompter[TReq, TLLMParams]
+	parser   parsers.Parser[TResp]
+}
+
+// NewChat returns a Predictor from the given ChatLLM.
+func NewChat[TReq, TResp, TLLMParams any](
+	model llms.ChatLLM[TLLMParams],
+	prompter prompters.ChatPrompter[TReq, TLLMParams],
+	parser parsers.Parser[TResp],
+) Predictor[TReq, TResp] {
+	return chatPredictor[TReq, TResp, TLLMParams]{
+		model:    model,
+		prompter: prompter,
+		parser:   parser,
+	}
+}
+
+// Predict implements Predictor.
+func (p chatPredictor[TReq, TResp, TLLMParams]) Predict(ctx context.Context, req TReq) (TResp, error) {
+	var empty TResp
+	messages, params, err := p.prompter.Hydrate(ctx, req)
+	if err != nil {
+		return empty, fmt.Errorf("%w: %v", prompters.ErrHydrate, err)
+	}
+
+	llmOutput, err := p.model.Chat(ctx, messages, params)
+	if err != nil {
+		return empty, fmt.Errorf("%w: %w", ErrLLM, err)
+	}
+
+	result, err := p.parser.Parse(llmOutput.Content)
+	if err != nil {
+		return empty, fmt.Errorf("%w: %v", ErrParse, err)
+	}
+
+	return result, nil
+}
+
+type multimodalPredictor[TReq, TResp, TLLMParams any] struct {
+	model    llms.LLM[TLLMParams]
+	prompter prompters.MultimodalPrompter[TReq, TLLMParams]
+	parser   parsers.Parser[TResp]
+}
+
+// NewMultimodal returns a Predictor that prompts the given LLM with text,
+// images and other files. The LLM should implement llms.MultimodalLLM,
+// otherwise only prompts made of text can be sent (see
+// llms.GenerateMultimodal). The responses are not streamed.
+func NewMultimodal[TReq, TResp, TLLMParams any](
+	model llms.LLM[TLLMParams],
+	prompter prompters.MultimodalPrompter[TReq, TLLMParams],
+	parser parsers.Parser[TResp],
+) Predictor[TReq, TResp] {
+	return multimodalPredictor[TReq, TResp, TLLMParams]{
+		model:    model,
+		prompter: prompter,
+		parser:   parser,
+	}
+}
+
+// Predict implements Predictor.
+func (p multimodalPredictor[TReq, TResp, TLLMParams]) Predict(ctx context.Context, req TReq) (TResp, error) {
+	var empty TResp
+	parts, params, err := p.prompter.Hydrate(ctx, req)
+	if err != nil {
+		return empty, fmt.Errorf("%w: %v", prompters.ErrHydrate, err)
+	}
+
+	llmOutput, err := llms.GenerateMultimodal(ctx, p.model, parts, params)
+	if err != nil {
+		return empty, fmt.Errorf("%w: %w", ErrLLM, err)
+	}
+	handleResult(ctx, llmOutput)
+
+	result, err := p.parser.Parse(llmOutput.Text)
+	if err != nil {
+		if llmOutput.FinishReason == llms.FinishReasonMaxTokens {
+			return empty, fmt.Errorf("%w: %v", ErrTruncated, err)
+		}
 		return empty, fmt.Errorf("%w: %v", ErrParse, err)
 	}
 
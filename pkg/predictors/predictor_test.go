@@ -19,6 +19,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/google/go-react/pkg/llms"
 	llmstesting "github.com/google/go-react/pkg/llms/testing"
 	parserstesting "github.com/google/go-react/pkg/parsers/testing"
 	"github.com/google/go-react/pkg/predictors"
@@ -104,6 +105,50 @@ func TestPredict(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "truncated LLM response fails to parse",
+			setup: func(m *llmstesting.Fake[LLMParams], p *prompterstesting.Fake[PromptData, LLMParams], parser *parserstesting.Fake[ParserData]) {
+				p.HydrateF = func(context.Context, PromptData) (string, LLMParams, error) {
+					return "some-prompt", 0, nil
+				}
+				m.Results = map[string]llms.Result{
+					"some-prompt": {Text: "some-truncated-", FinishReason: llms.FinishReasonMaxTokens},
+				}
+				parser.ParseF = func(intput string) (ParserData, error) {
+					return "", errors.New("some-error")
+				}
+			},
+			assert: func(t *testing.T, resp ParserData, err error, m *llmstesting.Fake[LLMParams], p *prompterstesting.Fake[PromptData, LLMParams], parser *parserstesting.Fake[ParserData]) {
+				if actual, expected := errors.Is(err, predictors.ErrTruncated), true; actual != expected {
+					t.Fatalf("expected %v, got %v", expected, actual)
+				}
+				if actual, expected := errors.Is(err, predictors.ErrParse), false; actual != expected {
+					t.Fatalf("expected %v, got %v", expected, actual)
+				}
+			},
+		},
+		{
+			name: "truncated LLM response parses",
+			setup: func(m *llmstesting.Fake[LLMParams], p *prompterstesting.Fake[PromptData, LLMParams], parser *parserstesting.Fake[ParserData]) {
+				p.HydrateF = func(context.Context, PromptData) (string, LLMParams, error) {
+					return "some-prompt", 0, nil
+				}
+				m.Results = map[string]llms.Result{
+					"some-prompt": {Text: "some-truncated-", FinishReason: llms.FinishReasonMaxTokens},
+				}
+				parser.ParseF = func(intput string) (ParserData, error) {
+					return "some-parsed-output", nil
+				}
+			},
+			assert: func(t *testing.T, resp ParserData, err error, m *llmstesting.Fake[LLMParams], p *prompterstesting.Fake[PromptData, LLMParams], parser *parserstesting.Fake[ParserData]) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := resp, ParserData("some-parsed-output"); actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -126,3 +171,197 @@ func TestPredict(t *testing.T) {
 		})
 	}
 }
+
+func TestPredictChat(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		setup  func(*llmstesting.FakeChat[LLMParams], *prompterstesting.FakeChat[PromptData, LLMParams], *parserstesting.Fake[ParserData])
+		assert func(t *testing.T, resp ParserData, err error, m *llmstesting.FakeChat[LLMParams], p *prompterstesting.FakeChat[PromptData, LLMParams], parser *parserstesting.Fake[ParserData])
+	}{
+		{
+			name: "success",
+			setup: func(m *llmstesting.FakeChat[LLMParams], p *prompterstesting.FakeChat[PromptData, LLMParams], parser *parserstesting.Fake[ParserData]) {
+				p.HydrateF = func(context.Context, PromptData) ([]llms.Message, LLMParams, error) {
+					return []llms.Message{{Role: llms.RoleUser, Content: "some-output"}}, 0, nil
+				}
+				m.AlwaysText = "some-llm-output"
+				parser.ParseF = func(intput string) (ParserData, error) {
+					return "some-parsed-output", nil
+				}
+			},
+			assert: func(t *testing.T, resp ParserData, err error, m *llmstesting.FakeChat[LLMParams], p *prompterstesting.FakeChat[PromptData, LLMParams], parser *parserstesting.Fake[ParserData]) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := p.HydrateData[0], PromptData(1); actual != expected {
+					t.Fatalf("expected %d, got %d", expected, actual)
+				}
+				if actual, expected := m.Messages[0][0].Content, "some-output"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if actual, expected := parser.Datas[0], "some-llm-output"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if actual, expected := resp, ParserData("some-parsed-output"); actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name: "hydrating prompt fails",
+			setup: func(m *llmstesting.FakeChat[LLMParams], p *prompterstesting.FakeChat[PromptData, LLMParams], parser *parserstesting.Fake[ParserData]) {
+				p.HydrateF = func(context.Context, PromptData) ([]llms.Message, LLMParams, error) {
+					return nil, 0, errors.New("some-error")
+				}
+			},
+			assert: func(t *testing.T, resp ParserData, err error, m *llmstesting.FakeChat[LLMParams], p *prompterstesting.FakeChat[PromptData, LLMParams], parser *parserstesting.Fake[ParserData]) {
+				if actual, expected := errors.Is(err, prompters.ErrHydrate), true; actual != expected {
+					t.Fatalf("expected %v, got %v", expected, actual)
+				}
+			},
+		},
+		{
+			name: "LLM prediction fails",
+			setup: func(m *llmstesting.FakeChat[LLMParams], p *prompterstesting.FakeChat[PromptData, LLMParams], parser *parserstesting.Fake[ParserData]) {
+				m.Err = errors.New("some-error")
+			},
+			assert: func(t *testing.T, resp ParserData, err error, m *llmstesting.FakeChat[LLMParams], p *prompterstesting.FakeChat[PromptData, LLMParams], parser *parserstesting.Fake[ParserData]) {
+				if actual, expected := errors.Is(err, predictors.ErrLLM), true; actual != expected {
+					t.Fatalf("expected %v, got %v", expected, actual)
+				}
+			},
+		},
+		{
+			name: "parsing LLM response fails",
+			setup: func(m *llmstesting.FakeChat[LLMParams], p *prompterstesting.FakeChat[PromptData, LLMParams], parser *parserstesting.Fake[ParserData]) {
+				parser.ParseF = func(intput string) (ParserData, error) {
+					return "", errors.New("some-error")
+				}
+			},
+			assert: func(t *testing.T, resp ParserData, err error, m *llmstesting.FakeChat[LLMParams], p *prompterstesting.FakeChat[PromptData, LLMParams], parser *parserstesting.Fake[ParserData]) {
+				if actual, expected := errors.Is(err, predictors.ErrParse), true; actual != expected {
+					t.Fatalf("expected %v, got %v", expected, actual)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			llm := &llmstesting.FakeChat[LLMParams]{}
+			prompter := &prompterstesting.FakeChat[PromptData, LLMParams]{}
+			parser := &parserstesting.Fake[ParserData]{}
+
+			if tc.setup != nil {
+				tc.setup(llm, prompter, parser)
+			}
+
+			predictor := predictors.NewChat[PromptData, ParserData, LLMParams](llm, prompter, parser)
+			reps, err := predictor.Predict(context.Background(), 1)
+			tc.assert(t, reps, err, llm, prompter, parser)
+		})
+	}
+}
+
+func TestPredictMultimodal(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		setup  func(*llmstesting.Fake[LLMParams], *prompterstesting.FakeMultimodal[PromptData, LLMParams], *parserstesting.Fake[ParserData])
+		assert func(t *testing.T, resp ParserData, err error, m *llmstesting.Fake[LLMParams], p *prompterstesting.FakeMultimodal[PromptData, LLMParams], parser *parserstesting.Fake[ParserData])
+	}{
+		{
+			name: "success",
+			setup: func(m *llmstesting.Fake[LLMParams], p *prompterstesting.FakeMultimodal[PromptData, LLMParams], parser *parserstesting.Fake[ParserData]) {
+				p.HydrateF = func(context.Context, PromptData) ([]llms.Part, LLMParams, error) {
+					return []llms.Part{
+						llms.TextPart("some-output"),
+						llms.DataPart("image/png", []byte("some-image")),
+					}, 0, nil
+				}
+				m.AlwaysText = "some-llm-output"
+				parser.ParseF = func(intput string) (ParserData, error) {
+					return "some-parsed-output", nil
+				}
+			},
+			assert: func(t *testing.T, resp ParserData, err error, m *llmstesting.Fake[LLMParams], p *prompterstesting.FakeMultimodal[PromptData, LLMParams], parser *parserstesting.Fake[ParserData]) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := string(m.Parts[0][1].Data), "some-image"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if actual, expected := parser.Datas[0], "some-llm-output"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if actual, expected := resp, ParserData("some-parsed-output"); actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name: "hydrating prompt fails",
+			setup: func(m *llmstesting.Fake[LLMParams], p *prompterstesting.FakeMultimodal[PromptData, LLMParams], parser *parserstesting.Fake[ParserData]) {
+				p.HydrateF = func(context.Context, PromptData) ([]llms.Part, LLMParams, error) {
+					return nil, 0, errors.New("some-error")
+				}
+			},
+			assert: func(t *testing.T, resp ParserData, err error, m *llmstesting.Fake[LLMParams], p *prompterstesting.FakeMultimodal[PromptData, LLMParams], parser *parserstesting.Fake[ParserData]) {
+				if actual, expected := errors.Is(err, prompters.ErrHydrate), true; actual != expected {
+					t.Fatalf("expected %v, got %v", expected, actual)
+				}
+			},
+		},
+		{
+			name: "LLM prediction fails",
+			setup: func(m *llmstesting.Fake[LLMParams], p *prompterstesting.FakeMultimodal[PromptData, LLMParams], parser *parserstesting.Fake[ParserData]) {
+				m.Err = errors.New("some-error")
+			},
+			assert: func(t *testing.T, resp ParserData, err error, m *llmstesting.Fake[LLMParams], p *prompterstesting.FakeMultimodal[PromptData, LLMParams], parser *parserstesting.Fake[ParserData]) {
+				if actual, expected := errors.Is(err, predictors.ErrLLM), true; actual != expected {
+					t.Fatalf("expected %v, got %v", expected, actual)
+				}
+			},
+		},
+		{
+			name: "parsing LLM response fails",
+			setup: func(m *llmstesting.Fake[LLMParams], p *prompterstesting.FakeMultimodal[PromptData, LLMParams], parser *parserstesting.Fake[ParserData]) {
+				parser.ParseF = func(intput string) (ParserData, error) {
+					return "", errors.New("some-error")
+				}
+			},
+			assert: func(t *testing.T, resp ParserData, err error, m *llmstesting.Fake[LLMParams], p *prompterstesting.FakeMultimodal[PromptData, LLMParams], parser *parserstesting.Fake[ParserData]) {
+				if actual, expected := errors.Is(err, predictors.ErrParse), true; actual != expected {
+					t.Fatalf("expected %v, got %v", expected, actual)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			llm := &llmstesting.Fake[LLMParams]{}
+			prompter := &prompterstesting.FakeMultimodal[PromptData, LLMParams]{}
+			parser := &parserstesting.Fake[ParserData]{}
+
+			if tc.setup != nil {
+				tc.setup(llm, prompter, parser)
+			}
+
+			predictor := predictors.NewMultimodal[PromptData, ParserData, LLMParams](llm, prompter, parser)
+			reps, err := predictor.Predict(context.Background(), 1)
+			tc.assert(t, reps, err, llm, prompter, parser)
+		})
+	}
+}
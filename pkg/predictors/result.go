@@ -0,0 +1,37 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package predictors
+
+import (
+	"context"
+
+	"github.com/google/go-react/pkg/llms"
+)
+
+type resultHandlerKey struct{}
+
+// WithResultHandler returns a context that makes Predictors created with New
+// call the handler with each result from the LLM (e.g., to record the token
+// usage). The result only has metadata when the LLM implements
+// llms.MetadataLLM and the response is not streamed.
+func WithResultHandler(ctx context.Context, handler func(result llms.Result)) context.Context {
+	return context.WithValue(ctx, resultHandlerKey{}, handler)
+}
+
+func handleResult(ctx context.Context, result llms.Result) {
+	if h, ok := ctx.Value(resultHandlerKey{}).(func(result llms.Result)); ok {
+		h(result)
+	}
+}
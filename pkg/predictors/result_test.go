@@ -0,0 +1,66 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package predictors_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-react/pkg/llms"
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+	parserstesting "github.com/google/go-react/pkg/parsers/testing"
+	"github.com/google/go-react/pkg/predictors"
+	prompterstesting "github.com/google/go-react/pkg/prompters/testing"
+)
+
+func TestWithResultHandler(t *testing.T) {
+	t.Parallel()
+
+	llm := &llmstesting.Fake[LLMParams]{
+		Results: map[string]llms.Result{
+			"some-prompt": {
+				Text:         "some-llm-output",
+				Usage:        llms.Usage{InputTokens: 3, OutputTokens: 5},
+				FinishReason: llms.FinishReasonStop,
+			},
+		},
+	}
+	prompter := &prompterstesting.Fake[PromptData, LLMParams]{
+		HydrateF: func(context.Context, PromptData) (string, LLMParams, error) {
+			return "some-prompt", 0, nil
+		},
+	}
+	parser := &parserstesting.Fake[ParserData]{}
+
+	var results []llms.Result
+	ctx := predictors.WithResultHandler(context.Background(), func(result llms.Result) {
+		results = append(results, result)
+	})
+
+	predictor := predictors.New[PromptData, ParserData, LLMParams](llm, prompter, parser)
+	if _, err := predictor.Predict(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if actual, expected := len(results), 1; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+	if actual, expected := results[0].Usage, (llms.Usage{InputTokens: 3, OutputTokens: 5}); actual != expected {
+		t.Fatalf("expected %+v, got %+v", expected, actual)
+	}
+	if actual, expected := parser.Datas[0], "some-llm-output"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+}
@@ -17,20 +17,46 @@ package predictors
 import (
 	"context"
 	"errors"
+	"time"
+
+	"github.com/google/go-react/pkg/llms"
 )
 
+// RetrierOption is an option for NewRetrier.
+type RetrierOption func(*retrierConfig)
+
+type retrierConfig struct {
+	maxWait time.Duration
+}
+
+// WithMaxRetryWait caps how long the retrier waits when the LLM's API asks it
+// to wait before retrying. It defaults to a minute.
+func WithMaxRetryWait(d time.Duration) RetrierOption {
+	return func(c *retrierConfig) {
+		c.maxWait = d
+	}
+}
+
 type retrier[TReq, TResp any] struct {
-	p Predictor[TReq, TResp]
+	p      Predictor[TReq, TResp]
+	config retrierConfig
 }
 
 // NewRetrier returns a Predictor that wraps the given Predictor. It will retry
-// on certain types of errors.
+// on certain types of errors: ErrParse, and ErrLLM when llms.IsRetryable says
+// the LLM's error is retryable. It waits before retrying when the LLM's API
+// asked it to (see llms.RetryAfter), for up to the maximum wait.
 func NewRetrier[TReq, TResp any](
 	p Predictor[TReq, TResp],
+	opts ...RetrierOption,
 ) Predictor[TReq, TResp] {
-	// TODO: We should likely support some configuration here at some point.
+	config := retrierConfig{maxWait: time.Minute}
+	for _, opt := range opts {
+		opt(&config)
+	}
 	return retrier[TReq, TResp]{
-		p: p,
+		p:      p,
+		config: config,
 	}
 }
 
@@ -40,19 +66,67 @@ func (r retrier[TReq, TResp]) Predict(ctx context.Context, req TReq) (TResp, err
 	var err error
 	for i := 0; i < 3; i++ {
 		resp, err = r.p.Predict(ctx, req)
-		if errors.Is(err, ErrLLM) {
-			// TODO: We should have metrics around this.
-			continue
+		if !retryable(err) {
+			// Error, if any, is not a retryable one.
+			return resp, err
 		}
-		if errors.Is(err, ErrParse) {
-			// TODO: We should have metrics around this.
-			continue
+		if i == 2 {
+			break
 		}
 
-		// Error, if any, is not a retryable one.
-		return resp, err
+		handleRetry(ctx, err)
+		if wait, ok := llms.RetryAfter(err); ok {
+			if wait > r.config.maxWait {
+				wait = r.config.maxWait
+			}
+			if err := sleep(ctx, wait); err != nil {
+				return resp, err
+			}
+		}
 	}
 
 	// Retying failed, return the last error.
 	return resp, err
 }
+
+func retryable(err error) bool {
+	if errors.Is(err, ErrLLM) {
+		return llms.IsRetryable(err)
+	}
+	return errors.Is(err, ErrParse)
+}
+
+type retryHandlerKey struct{}
+
+// WithRetryHandler returns a context that makes Predictors created with
+// NewRetrier call the handler with the error that is about to be retried
+// (e.g., to count the retries). The handlers that the context already has are
+// still called, before this one.
+func WithRetryHandler(ctx context.Context, handler func(err error)) context.Context {
+	if parent, ok := ctx.Value(retryHandlerKey{}).(func(err error)); ok {
+		h := handler
+		handler = func(err error) {
+			parent(err)
+			h(err)
+		}
+	}
+	return context.WithValue(ctx, retryHandlerKey{}, handler)
+}
+
+func handleRetry(ctx context.Context, err error) {
+	if h, ok := ctx.Value(retryHandlerKey{}).(func(err error)); ok {
+		h(err)
+	}
+}
+
+// sleep waits for d or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -16,9 +16,13 @@ package predictors_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/go-react/pkg/llms"
 	"github.com/google/go-react/pkg/predictors"
 	predictorstesting "github.com/google/go-react/pkg/predictors/testing"
 	"github.com/google/go-react/pkg/prompters"
@@ -100,6 +104,55 @@ func TestRetrier(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "LLM invalid request error - no retry",
+			setup: func(f *predictorstesting.Fake[PromptData, ParserData]) {
+				f.Err = fmt.Errorf("%w: %w", predictors.ErrLLM, llms.ErrInvalidRequest)
+			},
+			assert: func(t *testing.T, f *predictorstesting.Fake[PromptData, ParserData], resp ParserData, err error) {
+				if actual, expected := errors.Is(err, llms.ErrInvalidRequest), true; actual != expected {
+					t.Fatalf("expected %v, got %v", expected, actual)
+				}
+
+				if actual, expected := len(f.Reqs), 1; actual != expected {
+					t.Fatalf("expected %d, got %d", expected, actual)
+				}
+			},
+		},
+		{
+			name: "LLM rate limited error - retries after waiting",
+			setup: func(f *predictorstesting.Fake[PromptData, ParserData]) {
+				f.Err = fmt.Errorf("%w: %w", predictors.ErrLLM, &llms.APIError{
+					StatusCode: 429,
+					RetryAfter: 10 * time.Millisecond,
+					Err:        llms.ErrRateLimited,
+				})
+			},
+			assert: func(t *testing.T, f *predictorstesting.Fake[PromptData, ParserData], resp ParserData, err error) {
+				if actual, expected := errors.Is(err, llms.ErrRateLimited), true; actual != expected {
+					t.Fatalf("expected %v, got %v", expected, actual)
+				}
+
+				if actual, expected := len(f.Reqs), 3; actual != expected {
+					t.Fatalf("expected %d, got %d", expected, actual)
+				}
+			},
+		},
+		{
+			name: "Truncated error - no retry",
+			setup: func(f *predictorstesting.Fake[PromptData, ParserData]) {
+				f.Err = fmt.Errorf("%w: some-error", predictors.ErrTruncated)
+			},
+			assert: func(t *testing.T, f *predictorstesting.Fake[PromptData, ParserData], resp ParserData, err error) {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+
+				if actual, expected := len(f.Reqs), 1; actual != expected {
+					t.Fatalf("expected %d, got %d", expected, actual)
+				}
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -118,3 +171,78 @@ func TestRetrier(t *testing.T) {
 		})
 	}
 }
+
+func TestRetrier_retryHandler(t *testing.T) {
+	t.Parallel()
+
+	f := &predictorstesting.Fake[PromptData, ParserData]{
+		Err: fmt.Errorf("%w: some-error", predictors.ErrParse),
+	}
+
+	var retried []error
+	ctx := predictors.WithRetryHandler(context.Background(), func(err error) {
+		retried = append(retried, err)
+	})
+
+	r := predictors.NewRetrier[PromptData, ParserData](f)
+	if _, err := r.Predict(ctx, 99); !errors.Is(err, predictors.ErrParse) {
+		t.Fatalf("expected %v, got %v", predictors.ErrParse, err)
+	}
+
+	// The last attempt is not retried.
+	if actual, expected := len(retried), 2; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+	if actual, expected := errors.Is(retried[0], predictors.ErrParse), true; actual != expected {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestRetrier_retryHandlers(t *testing.T) {
+	t.Parallel()
+
+	f := &predictorstesting.Fake[PromptData, ParserData]{
+		Err: fmt.Errorf("%w: some-error", predictors.ErrParse),
+	}
+
+	var calls []string
+	ctx := predictors.WithRetryHandler(context.Background(), func(err error) {
+		calls = append(calls, "outer")
+	})
+	ctx = predictors.WithRetryHandler(ctx, func(err error) {
+		calls = append(calls, "inner")
+	})
+
+	r := predictors.NewRetrier[PromptData, ParserData](f)
+	if _, err := r.Predict(ctx, 99); !errors.Is(err, predictors.ErrParse) {
+		t.Fatalf("expected %v, got %v", predictors.ErrParse, err)
+	}
+
+	// Both handlers are called for each retry, the outer one first.
+	if actual, expected := strings.Join(calls, ","), "outer,inner,outer,inner"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+}
+
+func TestRetrier_maxRetryWait(t *testing.T) {
+	t.Parallel()
+
+	f := &predictorstesting.Fake[PromptData, ParserData]{
+		Err: fmt.Errorf("%w: %w", predictors.ErrLLM, &llms.APIError{
+			StatusCode: 429,
+			RetryAfter: time.Hour,
+			Err:        llms.ErrRateLimited,
+		}),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	r := predictors.NewRetrier[PromptData, ParserData](f, predictors.WithMaxRetryWait(10*time.Millisecond))
+	if _, err := r.Predict(ctx, 99); !errors.Is(err, llms.ErrRateLimited) {
+		t.Fatalf("expected %v, got %v", llms.ErrRateLimited, err)
+	}
+	if actual, expected := len(f.Reqs), 3; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+}
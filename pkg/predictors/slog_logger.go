@@ -0,0 +1,69 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package predictors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+type slogLogger[TReq, TResp any] struct {
+	p      Predictor[TReq, TResp]
+	logger *slog.Logger
+}
+
+// NewSlogLogger chains a Predictor that logs each prediction to the given
+// slog.Logger with the request, response, latency and error as attributes.
+// The request and response are logged as JSON strings, so that a
+// logging.NewHandler can redact and truncate them.
+func NewSlogLogger[TReq, TResp any](p Predictor[TReq, TResp], logger *slog.Logger) Predictor[TReq, TResp] {
+	return slogLogger[TReq, TResp]{
+		p:      p,
+		logger: logger,
+	}
+}
+
+// Predict implements Predictor.
+func (l slogLogger[TReq, TResp]) Predict(ctx context.Context, req TReq) (TResp, error) {
+	start := time.Now()
+	resp, err := l.p.Predict(ctx, req)
+
+	attrs := []slog.Attr{
+		slog.String("request", toJSON(req)),
+		slog.Duration("latency", time.Since(start)),
+	}
+	level := slog.LevelInfo
+	if err != nil {
+		level = slog.LevelError
+		attrs = append(attrs, slog.Any("error", err))
+	} else {
+		attrs = append(attrs, slog.String("response", toJSON(resp)))
+	}
+	l.logger.LogAttrs(ctx, level, "predictor predict", attrs...)
+
+	return resp, err
+}
+
+// toJSON encodes v as JSON, falling back to its Go syntax when it can't be.
+func toJSON(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%+v", v)
+	}
+	return string(b)
+}
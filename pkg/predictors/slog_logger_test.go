@@ -0,0 +1,85 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package predictors_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/google/go-react/pkg/predictors"
+	predictorstesting "github.com/google/go-react/pkg/predictors/testing"
+)
+
+func TestSlogLogger(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		fake     *predictorstesting.Fake[PromptData, ParserData]
+		expected map[string]any
+	}{
+		{
+			name: "success",
+			fake: &predictorstesting.Fake[PromptData, ParserData]{
+				Resps: []ParserData{"some-response"},
+			},
+			expected: map[string]any{
+				"level":    "INFO",
+				"request":  "1",
+				"response": `"some-response"`,
+			},
+		},
+		{
+			name: "error",
+			fake: &predictorstesting.Fake[PromptData, ParserData]{
+				Err: errors.New("some-error"),
+			},
+			expected: map[string]any{
+				"level":   "ERROR",
+				"request": "1",
+				"error":   "some-error",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			p := predictors.NewSlogLogger[PromptData, ParserData](tc.fake, slog.New(slog.NewJSONHandler(&buf, nil)))
+			p.Predict(context.Background(), 1)
+
+			var m map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+				t.Fatal(err)
+			}
+			if actual, expected := m["msg"], "predictor predict"; actual != expected {
+				t.Errorf("expected %q, got %q", expected, actual)
+			}
+			for k, v := range tc.expected {
+				if actual, expected := m[k], v; actual != expected {
+					t.Errorf("%s: expected %q, got %q", k, expected, actual)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package predictors
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/google/go-react/pkg/llms"
+)
+
+type streamHandlerKey struct{}
+
+// WithStreamHandler returns a context that makes Predictors created with New
+// stream the response from the LLM. The handler is called with each chunk of
+// text as it arrives, before the full response is parsed. LLMs that do not
+// implement llms.StreamLLM produce a single chunk.
+func WithStreamHandler(ctx context.Context, handler func(chunk string)) context.Context {
+	return context.WithValue(ctx, streamHandlerKey{}, handler)
+}
+
+func streamHandler(ctx context.Context) func(chunk string) {
+	h, _ := ctx.Value(streamHandlerKey{}).(func(chunk string))
+	return h
+}
+
+// generate calls the LLM, streaming the response to the context's stream
+// handler if there is one. The result is passed to the context's result
+// handler if there is one. Streamed responses don't have any metadata.
+func generate[TLLMParams any](ctx context.Context, model llms.LLM[TLLMParams], prompt string, params TLLMParams) (llms.Result, error) {
+	handler := streamHandler(ctx)
+	if handler == nil {
+		result, err := llms.GenerateWithMetadata(ctx, model, prompt, params)
+		if err != nil {
+			return llms.Result{}, err
+		}
+		handleResult(ctx, result)
+		return result, nil
+	}
+
+	s, err := llms.GenerateStream(ctx, model, prompt, params)
+	if err != nil {
+		return llms.Result{}, err
+	}
+	defer s.Close()
+
+	var b strings.Builder
+	for {
+		chunk, err := s.Recv()
+		if errors.Is(err, io.EOF) {
+			result := llms.Result{Text: b.String()}
+			handleResult(ctx, result)
+			return result, nil
+		}
+		if err != nil {
+			return llms.Result{}, err
+		}
+		handler(chunk)
+		b.WriteString(chunk)
+	}
+}
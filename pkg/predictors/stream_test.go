@@ -0,0 +1,95 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package predictors_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+	parserstesting "github.com/google/go-react/pkg/parsers/testing"
+	"github.com/google/go-react/pkg/predictors"
+	prompterstesting "github.com/google/go-react/pkg/prompters/testing"
+)
+
+func TestWithStreamHandler(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		setup  func(*llmstesting.Fake[LLMParams])
+		assert func(t *testing.T, chunks []string, parsed []string, err error)
+	}{
+		{
+			name: "streams chunks then parses",
+			setup: func(m *llmstesting.Fake[LLMParams]) {
+				m.Chunks = map[string][]string{"some-prompt": {"some-", "llm-", "output"}}
+			},
+			assert: func(t *testing.T, chunks []string, parsed []string, err error) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if actual, expected := strings.Join(chunks, "|"), "some-|llm-|output"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+				if actual, expected := parsed[0], "some-llm-output"; actual != expected {
+					t.Fatalf("expected %q, got %q", expected, actual)
+				}
+			},
+		},
+		{
+			name: "LLM fails",
+			setup: func(m *llmstesting.Fake[LLMParams]) {
+				m.Err = errors.New("some-error")
+			},
+			assert: func(t *testing.T, chunks []string, parsed []string, err error) {
+				if actual, expected := errors.Is(err, predictors.ErrLLM), true; actual != expected {
+					t.Fatalf("expected %v, got %v", expected, actual)
+				}
+				if actual, expected := len(chunks), 0; actual != expected {
+					t.Fatalf("expected %d, got %d", expected, actual)
+				}
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			llm := &llmstesting.Fake[LLMParams]{}
+			prompter := &prompterstesting.Fake[PromptData, LLMParams]{
+				HydrateF: func(context.Context, PromptData) (string, LLMParams, error) {
+					return "some-prompt", 0, nil
+				},
+			}
+			parser := &parserstesting.Fake[ParserData]{}
+			tc.setup(llm)
+
+			var chunks []string
+			ctx := predictors.WithStreamHandler(context.Background(), func(chunk string) {
+				chunks = append(chunks, chunk)
+			})
+
+			predictor := predictors.New[PromptData, ParserData, LLMParams](llm, prompter, parser)
+			_, err := predictor.Predict(ctx, 1)
+			tc.assert(t, chunks, parser.Datas, err)
+		})
+	}
+}
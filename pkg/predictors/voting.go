@@ -0,0 +1,167 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package predictors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/go-react/pkg/llms"
+	"github.com/google/go-react/pkg/parsers"
+	"github.com/google/go-react/pkg/prompters"
+)
+
+// VotingOption is an option for NewVoting.
+type VotingOption[TResp any] func(*votingPredictorConfig[TResp])
+
+type votingPredictorConfig[TResp any] struct {
+	key   func(TResp) string
+	score func(TResp) float64
+}
+
+// WithVoteKey sets the function used to decide which parsed candidates are the
+// same answer. It defaults to comparing the JSON encoding of the candidates.
+// It is useful when only part of the response matters (e.g., the answer but
+// not the explanation).
+func WithVoteKey[TResp any](key func(TResp) string) VotingOption[TResp] {
+	return func(c *votingPredictorConfig[TResp]) {
+		c.key = key
+	}
+}
+
+// WithScorer makes the predictor return the candidate with the highest score
+// instead of the most common one.
+func WithScorer[TResp any](score func(TResp) float64) VotingOption[TResp] {
+	return func(c *votingPredictorConfig[TResp]) {
+		c.score = score
+	}
+}
+
+type votingPredictor[TReq, TResp, TLLMParams any] struct {
+	model    llms.LLM[TLLMParams]
+	prompter prompters.Prompter[TReq, TLLMParams]
+	parser   parsers.Parser[TResp]
+	n        int
+	config   votingPredictorConfig[TResp]
+}
+
+// NewVoting returns a Predictor that asks the LLM for n candidates, parses each
+// of them and returns the answer that the most candidates agree on
+// (self-consistency). Ties go to the answer that was generated first.
+// Candidates that fail to parse are ignored, and ErrParse is only returned
+// when none of them parse. LLMs that do not implement llms.CandidatesLLM are
+// called n times.
+func NewVoting[TReq, TResp, TLLMParams any](
+	model llms.LLM[TLLMParams],
+	prompter prompters.Prompter[TReq, TLLMParams],
+	parser parsers.Parser[TResp],
+	n int,
+	opts ...VotingOption[TResp],
+) Predictor[TReq, TResp] {
+	if n < 1 {
+		panic("n must be at least 1")
+	}
+
+	config := votingPredictorConfig[TResp]{
+		key: func(resp TResp) string {
+			data, err := json.Marshal(resp)
+			if err != nil {
+				return fmt.Sprintf("%#v", resp)
+			}
+			return string(data)
+		},
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return votingPredictor[TReq, TResp, TLLMParams]{
+		model:    model,
+		prompter: prompter,
+		parser:   parser,
+		n:        n,
+		config:   config,
+	}
+}
+
+// Predict implements Predictor.
+func (p votingPredictor[TReq, TResp, TLLMParams]) Predict(ctx context.Context, req TReq) (TResp, error) {
+	var empty TResp
+	prompt, params, err := p.prompter.Hydrate(ctx, req)
+	if err != nil {
+		return empty, fmt.Errorf("%w: %v", prompters.ErrHydrate, err)
+	}
+
+	results, err := llms.GenerateCandidates(ctx, p.model, prompt, params, p.n)
+	if err != nil {
+		return empty, fmt.Errorf("%w: %w", ErrLLM, err)
+	}
+
+	var (
+		candidates []TResp
+		parseErr   error
+	)
+	for _, result := range results {
+		handleResult(ctx, result)
+
+		resp, err := p.parser.Parse(result.Text)
+		if err != nil {
+			parseErr = err
+			continue
+		}
+		candidates = append(candidates, resp)
+	}
+	if len(candidates) == 0 {
+		if parseErr == nil {
+			return empty, fmt.Errorf("%w: no candidates returned", ErrLLM)
+		}
+		return empty, fmt.Errorf("%w: none of the %d candidates parsed: %v", ErrParse, len(results), parseErr)
+	}
+
+	if p.config.score != nil {
+		return p.best(candidates), nil
+	}
+	return p.mostCommon(candidates), nil
+}
+
+// best returns the candidate with the highest score.
+func (p votingPredictor[TReq, TResp, TLLMParams]) best(candidates []TResp) TResp {
+	best, bestScore := candidates[0], p.config.score(candidates[0])
+	for _, c := range candidates[1:] {
+		if score := p.config.score(c); score > bestScore {
+			best, bestScore = c, score
+		}
+	}
+	return best
+}
+
+// mostCommon returns the first candidate of the answer with the most votes.
+func (p votingPredictor[TReq, TResp, TLLMParams]) mostCommon(candidates []TResp) TResp {
+	votes := map[string]int{}
+	keys := make([]string, len(candidates))
+	for i, c := range candidates {
+		keys[i] = p.config.key(c)
+		votes[keys[i]]++
+	}
+
+	winner := 0
+	for i := range candidates {
+		if votes[keys[i]] > votes[keys[winner]] {
+			winner = i
+		}
+	}
+	return candidates[winner]
+}
@@ -0,0 +1,147 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package predictors_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-react/pkg/llms"
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+	parserstesting "github.com/google/go-react/pkg/parsers/testing"
+	"github.com/google/go-react/pkg/predictors"
+	prompterstesting "github.com/google/go-react/pkg/prompters/testing"
+)
+
+func TestVoting(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name       string
+		candidates []string
+		opts       []predictors.VotingOption[ParserData]
+		expected   ParserData
+		err        error
+	}{
+		{
+			name:       "majority",
+			candidates: []string{"a", "b", "b", "c"},
+			expected:   "b",
+		},
+		{
+			name:       "tie goes to the first answer",
+			candidates: []string{"a", "b", "b", "a"},
+			expected:   "a",
+		},
+		{
+			name:       "candidates that fail to parse are ignored",
+			candidates: []string{"bad", "bad", "a"},
+			expected:   "a",
+		},
+		{
+			name:       "all candidates fail to parse",
+			candidates: []string{"bad", "bad"},
+			err:        predictors.ErrParse,
+		},
+		{
+			name:       "vote key",
+			candidates: []string{"a: 1", "b: 2", "c: 2"},
+			opts: []predictors.VotingOption[ParserData]{
+				predictors.WithVoteKey(func(resp ParserData) string {
+					_, key, _ := strings.Cut(string(resp), ": ")
+					return key
+				}),
+			},
+			expected: "b: 2",
+		},
+		{
+			name:       "scorer",
+			candidates: []string{"a", "ccc", "b", "b", "dd"},
+			opts: []predictors.VotingOption[ParserData]{
+				predictors.WithScorer(func(resp ParserData) float64 {
+					return float64(len(resp))
+				}),
+			},
+			expected: "ccc",
+		},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var results []llms.Result
+			for _, c := range tc.candidates {
+				results = append(results, llms.Result{Text: c})
+			}
+			llm := &llmstesting.Fake[LLMParams]{
+				Candidates: map[string][]llms.Result{"some-prompt": results},
+			}
+			prompter := &prompterstesting.Fake[PromptData, LLMParams]{
+				HydrateF: func(context.Context, PromptData) (string, LLMParams, error) {
+					return "some-prompt", 0, nil
+				},
+			}
+			parser := &parserstesting.Fake[ParserData]{
+				ParseF: func(input string) (ParserData, error) {
+					if input == "bad" {
+						return "", errors.New("some-error")
+					}
+					return ParserData(input), nil
+				},
+			}
+
+			predictor := predictors.NewVoting[PromptData, ParserData, LLMParams](llm, prompter, parser, len(tc.candidates), tc.opts...)
+			resp, err := predictor.Predict(context.Background(), 1)
+			if tc.err != nil {
+				if actual, expected := errors.Is(err, tc.err), true; actual != expected {
+					t.Fatalf("expected %v, got %v: %v", expected, actual, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual, expected := resp, tc.expected; actual != expected {
+				t.Fatalf("expected %q, got %q", expected, actual)
+			}
+			if actual, expected := len(llm.Prompts), 1; actual != expected {
+				t.Fatalf("expected %d, got %d", expected, actual)
+			}
+		})
+	}
+}
+
+func TestVoting_fallback(t *testing.T) {
+	t.Parallel()
+
+	// Hide the optional interfaces so that the LLM is called once per
+	// candidate.
+	llm := &llmstesting.Fake[LLMParams]{AlwaysText: "some-output"}
+	prompter := &prompterstesting.Fake[PromptData, LLMParams]{}
+	parser := &parserstesting.Fake[ParserData]{}
+
+	predictor := predictors.NewVoting[PromptData, ParserData, LLMParams](struct{ llms.LLM[LLMParams] }{llm}, prompter, parser, 3)
+	if _, err := predictor.Predict(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := len(llm.Prompts), 3; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompters
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"text/template"
+
+	"github.com/google/go-react/pkg/llms"
+)
+
+// MessageTemplate is a Go Text Template that is hydrated into a single
+// llms.Message with the given Role.
+type MessageTemplate struct {
+	Role llms.Role
+	Text string
+}
+
+// NewChatTemplate returns a ChatPrompter that hydrates each of the given
+// MessageTemplates, in order, to build up the conversation. It shares the
+// template functions and Options with NewTextTemplate.
+func NewChatTemplate[TPrompt, TLLMParams any](
+	messages []MessageTemplate,
+	params TLLMParams,
+	opts ...Option[TPrompt],
+) ChatPrompter[TPrompt, TLLMParams] {
+	c := &chatTemplate[TPrompt, TLLMParams]{
+		opts:   opts,
+		params: params,
+	}
+	for i, m := range messages {
+		c.roles = append(c.roles, m.Role)
+		c.tmpls = append(c.tmpls, newTemplate(fmt.Sprintf("message-%d", i), m.Text))
+	}
+	return c
+}
+
+type chatTemplate[TPrompt, TLLMParams any] struct {
+	roles  []llms.Role
+	tmpls  []*template.Template
+	params TLLMParams
+	opts   []Option[TPrompt]
+}
+
+// Hydrate implements ChatPrompter.
+func (p *chatTemplate[TPrompt, TLLMParams]) Hydrate(ctx context.Context, obj TPrompt) ([]llms.Message, TLLMParams, error) {
+	for _, opt := range p.opts {
+		obj = opt(obj)
+	}
+
+	messages := make([]llms.Message, 0, len(p.tmpls))
+	for i, tmpl := range p.tmpls {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, obj); err != nil {
+			var empty TLLMParams
+			return nil, empty, fmt.Errorf("%w: %v", ErrHydrate, err)
+		}
+		messages = append(messages, llms.Message{
+			Role:    p.roles[i],
+			Content: buf.String(),
+		})
+	}
+	return messages, p.params, nil
+}
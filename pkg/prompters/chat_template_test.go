@@ -0,0 +1,83 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompters_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-react/pkg/llms"
+	"github.com/google/go-react/pkg/prompters"
+)
+
+func TestChatTemplate(t *testing.T) {
+	t.Parallel()
+	p := prompters.NewChatTemplate[map[string]any, int](
+		[]prompters.MessageTemplate{
+			{Role: llms.RoleSystem, Text: "You greet people."},
+			{Role: llms.RoleUser, Text: "Hello {{.Name}}"},
+		},
+		99,
+		func(p map[string]any) map[string]any {
+			p["Name"] = strings.ToUpper(p["Name"].(string))
+			return p
+		},
+	)
+	messages, params, err := p.Hydrate(context.Background(), map[string]any{
+		"Name": "World",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := len(messages), 2; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+	if actual, expected := messages[0], (llms.Message{Role: llms.RoleSystem, Content: "You greet people."}); actual != expected {
+		t.Fatalf("expected %+v, got %+v", expected, actual)
+	}
+	if actual, expected := messages[1], (llms.Message{Role: llms.RoleUser, Content: "Hello WORLD"}); actual != expected {
+		t.Fatalf("expected %+v, got %+v", expected, actual)
+	}
+	if actual, expected := params, 99; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+}
+
+func TestChatTemplate_InvalidTemplate(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	prompters.NewChatTemplate[map[string]any, int]([]prompters.MessageTemplate{
+		{Role: llms.RoleUser, Text: "Hello {{.Name"},
+	}, 99)
+}
+
+func TestChatTemplate_InvalidData(t *testing.T) {
+	t.Parallel()
+
+	p := prompters.NewChatTemplate[map[string]any, int]([]prompters.MessageTemplate{
+		{Role: llms.RoleUser, Text: "Hello {{.Unknown}}"},
+	}, 99)
+	_, _, err := p.Hydrate(context.Background(), nil)
+	if actual, expected := errors.Is(err, prompters.ErrHydrate), true; actual != expected {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompters
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/google/go-react/pkg/llms"
+)
+
+// partMarker surrounds the index of a part in the executed template, so that
+// the text can be split around the parts.
+const partMarker = "\x00"
+
+// NewMultimodalTemplate returns a MultimodalPrompter that uses a Go Text
+// Template to generate the parts of a prompt. Along with the template
+// functions of NewTextTemplate, the template can call Part with an llms.Part
+// (e.g., {{Part .Screenshot}}) to insert it between the text around it.
+func NewMultimodalTemplate[TPrompt, TLLMParams any](
+	text string,
+	params TLLMParams,
+	opts ...Option[TPrompt],
+) MultimodalPrompter[TPrompt, TLLMParams] {
+	return &multimodalTemplate[TPrompt, TLLMParams]{
+		opts:   opts,
+		params: params,
+		// Part is replaced for each Hydrate so that it can collect the parts.
+		tmpl: newTemplate("prompt", text, template.FuncMap{
+			"Part": func(llms.Part) string { return "" },
+		}),
+	}
+}
+
+type multimodalTemplate[TPrompt, TLLMParams any] struct {
+	tmpl   *template.Template
+	params TLLMParams
+	opts   []Option[TPrompt]
+}
+
+// Hydrate implements MultimodalPrompter.
+func (p *multimodalTemplate[TPrompt, TLLMParams]) Hydrate(ctx context.Context, obj TPrompt) ([]llms.Part, TLLMParams, error) {
+	var empty TLLMParams
+	for _, opt := range p.opts {
+		obj = opt(obj)
+	}
+
+	tmpl, err := p.tmpl.Clone()
+	if err != nil {
+		return nil, empty, fmt.Errorf("%w: %v", ErrHydrate, err)
+	}
+	var inserted []llms.Part
+	tmpl.Funcs(template.FuncMap{
+		"Part": func(part llms.Part) string {
+			inserted = append(inserted, part)
+			return partMarker + strconv.Itoa(len(inserted)-1) + partMarker
+		},
+	})
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, obj); err != nil {
+		return nil, empty, fmt.Errorf("%w: %v", ErrHydrate, err)
+	}
+
+	// The text and the indexes of the inserted parts alternate.
+	var parts []llms.Part
+	for i, s := range strings.Split(buf.String(), partMarker) {
+		if i%2 == 0 {
+			if s != "" {
+				parts = append(parts, llms.TextPart(s))
+			}
+			continue
+		}
+		j, err := strconv.Atoi(s)
+		if err != nil || j >= len(inserted) {
+			return nil, empty, fmt.Errorf("%w: the prompt has an unexpected NUL character", ErrHydrate)
+		}
+		parts = append(parts, inserted[j])
+	}
+	return parts, p.params, nil
+}
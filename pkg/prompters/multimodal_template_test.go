@@ -0,0 +1,72 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompters_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-react/pkg/llms"
+	"github.com/google/go-react/pkg/prompters"
+)
+
+func TestMultimodalTemplate(t *testing.T) {
+	t.Parallel()
+
+	type Data struct {
+		Name       string
+		Screenshot llms.Part
+		Files      []llms.Part
+	}
+	p := prompters.NewMultimodalTemplate[Data, int](
+		`Build the {{.Name}} table from this: {{Part .Screenshot}}{{range .Files}}{{Part .}}{{end}}Use {{ToJSON .Name}}.`,
+		99,
+	)
+	parts, params, err := p.Hydrate(context.Background(), Data{
+		Name:       "some-table",
+		Screenshot: llms.DataPart("image/png", []byte("some-image")),
+		Files: []llms.Part{
+			llms.FilePart("text/csv", "gs://some-bucket/some-file.csv"),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []llms.Part{
+		llms.TextPart("Build the some-table table from this: "),
+		llms.DataPart("image/png", []byte("some-image")),
+		llms.FilePart("text/csv", "gs://some-bucket/some-file.csv"),
+		llms.TextPart(`Use "some-table".`),
+	}
+	if !reflect.DeepEqual(parts, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, parts)
+	}
+	if actual, expected := params, 99; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+}
+
+func TestMultimodalTemplate_InvalidData(t *testing.T) {
+	t.Parallel()
+
+	p := prompters.NewMultimodalTemplate[map[string]any, int]("Hello {{.Unknown}}", 99)
+	_, _, err := p.Hydrate(context.Background(), nil)
+	if actual, expected := errors.Is(err, prompters.ErrHydrate), true; actual != expected {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+}
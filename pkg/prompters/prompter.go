@@ -17,6 +17,8 @@ package prompters
 import (
 	"context"
 	"errors"
+
+	"github.com/google/go-react/pkg/llms"
 )
 
 var (
@@ -31,3 +33,18 @@ type Prompter[TPrompt, TLLMParams any] interface {
 	// Hydrate hydrates the prompt with the given type.
 	Hydrate(context.Context, TPrompt) (string, TLLMParams, error)
 }
+
+// ChatPrompter is an interface for a generic prompt that can be hydrated with
+// the given type into a conversation for a llms.ChatLLM.
+type ChatPrompter[TPrompt, TLLMParams any] interface {
+	// Hydrate hydrates the conversation with the given type.
+	Hydrate(context.Context, TPrompt) ([]llms.Message, TLLMParams, error)
+}
+
+// MultimodalPrompter is an interface for a generic prompt that can be hydrated
+// with the given type into the parts of a multimodal prompt (e.g., text and
+// images) for a llms.MultimodalLLM.
+type MultimodalPrompter[TPrompt, TLLMParams any] interface {
+	// Hydrate hydrates the parts with the given type.
+	Hydrate(context.Context, TPrompt) ([]llms.Part, TLLMParams, error)
+}
@@ -0,0 +1,56 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompters
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+type slogLogger[TPrompt, TLLMParams any] struct {
+	p      Prompter[TPrompt, TLLMParams]
+	logger *slog.Logger
+}
+
+// NewSlogLogger logs each hydrated prompt to the given slog.Logger with the
+// prompt, params, latency and error as attributes. Use a logging.NewHandler
+// to redact and truncate them.
+func NewSlogLogger[TPrompt, TLLMParams any](p Prompter[TPrompt, TLLMParams], logger *slog.Logger) Prompter[TPrompt, TLLMParams] {
+	return slogLogger[TPrompt, TLLMParams]{
+		p:      p,
+		logger: logger,
+	}
+}
+
+// Hydrate implements Prompter.
+func (l slogLogger[TPrompt, TLLMParams]) Hydrate(ctx context.Context, p TPrompt) (string, TLLMParams, error) {
+	start := time.Now()
+	output, params, err := l.p.Hydrate(ctx, p)
+
+	attrs := []slog.Attr{
+		slog.String("prompt", output),
+		slog.Any("params", params),
+		slog.Duration("latency", time.Since(start)),
+	}
+	level := slog.LevelInfo
+	if err != nil {
+		level = slog.LevelError
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	l.logger.LogAttrs(ctx, level, "prompter hydrate", attrs...)
+
+	return output, params, err
+}
@@ -0,0 +1,62 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prompters_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/google/go-react/pkg/logging"
+	"github.com/google/go-react/pkg/prompters"
+)
+
+func TestSlogLogger(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	logger := slog.New(logging.NewHandler(
+		slog.NewJSONHandler(&buf, nil),
+		logging.WithRedactors(logging.RedactEmails()),
+	))
+	p := prompters.NewSlogLogger(prompters.NewTextTemplate[map[string]any, int]("Email {{.email}}", 99), logger)
+
+	output, params, err := p.Hydrate(context.Background(), map[string]any{"email": "some@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := output, "Email some@example.com"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+	if actual, expected := params, 99; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+
+	var m map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := m["msg"], "prompter hydrate"; actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+	if actual, expected := m["prompt"], "Email [REDACTED]"; actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+	if actual, expected := m["params"], 99.0; actual != expected {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
@@ -15,7 +15,11 @@
 // Package testing contains the fake prompter for testing.
 package testing
 
-import "context"
+import (
+	"context"
+
+	"github.com/google/go-react/pkg/llms"
+)
 
 // Fake is a fake prompter for testing.
 type Fake[TPrompt, TLLMParams any] struct {
@@ -32,3 +36,35 @@ func (f *Fake[TPrompt, TLLMParams]) Hydrate(ctx context.Context, data TPrompt) (
 	}
 	return f.HydrateF(ctx, data)
 }
+
+// FakeChat is a fake chat prompter for testing.
+type FakeChat[TPrompt, TLLMParams any] struct {
+	HydrateData []TPrompt
+	HydrateF    func(ctx context.Context, vars TPrompt) ([]llms.Message, TLLMParams, error)
+}
+
+// Hydrate hydrates the fake chat prompter.
+func (f *FakeChat[TPrompt, TLLMParams]) Hydrate(ctx context.Context, data TPrompt) ([]llms.Message, TLLMParams, error) {
+	f.HydrateData = append(f.HydrateData, data)
+	if f.HydrateF == nil {
+		var empty TLLMParams
+		return nil, empty, nil
+	}
+	return f.HydrateF(ctx, data)
+}
+
+// FakeMultimodal is a fake multimodal prompter for testing.
+type FakeMultimodal[TPrompt, TLLMParams any] struct {
+	HydrateData []TPrompt
+	HydrateF    func(ctx context.Context, vars TPrompt) ([]llms.Part, TLLMParams, error)
+}
+
+// Hydrate hydrates the fake multimodal prompter.
+func (f *FakeMultimodal[TPrompt, TLLMParams]) Hydrate(ctx context.Context, data TPrompt) ([]llms.Part, TLLMParams, error) {
+	f.HydrateData = append(f.HydrateData, data)
+	if f.HydrateF == nil {
+		var empty TLLMParams
+		return nil, empty, nil
+	}
+	return f.HydrateF(ctx, data)
+}
@@ -36,22 +36,32 @@ func NewTextTemplate[TPrompt, TLLMParams any](
 	return &textTemplate[TPrompt, TLLMParams]{
 		opts:   opts,
 		params: params,
-		tmpl: template.Must(template.
-			New("prompt").
-			Option("missingkey=error").
-			Funcs(template.FuncMap{
-				"ToJSON": func(v any) string {
-					b, err := json.Marshal(v)
-					if err != nil {
-						panic(err)
-					}
-					return string(b)
-				},
-			}).
-			Parse(text)),
+		tmpl:   newTemplate("prompt", text),
 	}
 }
 
+// newTemplate parses the given text into a template that has the functions
+// available to all prompt templates, along with any extra functions. It panics
+// if the text is invalid.
+func newTemplate(name, text string, funcs ...template.FuncMap) *template.Template {
+	tmpl := template.
+		New(name).
+		Option("missingkey=error").
+		Funcs(template.FuncMap{
+			"ToJSON": func(v any) string {
+				b, err := json.Marshal(v)
+				if err != nil {
+					panic(err)
+				}
+				return string(b)
+			},
+		})
+	for _, f := range funcs {
+		tmpl = tmpl.Funcs(f)
+	}
+	return template.Must(tmpl.Parse(text))
+}
+
 type textTemplate[TPrompt, TLLMParams any] struct {
 	tmpl   *template.Template
 	params TLLMParams
@@ -0,0 +1,75 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-react/pkg/llms"
+)
+
+// Declaration returns the function declaration of the tool for LLMs that
+// support function calling (see llms.FunctionCallingLLM). A tool takes a
+// single string, so the function has a single "input" argument that is
+// described by the tool's Args and Examples.
+func (t Tool) Declaration() llms.FunctionDeclaration {
+	var input strings.Builder
+	input.WriteString("The input to the tool.")
+	if len(t.Args) > 0 {
+		input.WriteString(" Usage: ")
+		for _, arg := range t.Args {
+			fmt.Fprintf(&input, "[%s]", arg)
+		}
+	}
+	if len(t.Examples) > 0 {
+		fmt.Fprintf(&input, " Examples: %s", strings.Join(t.Examples, ", "))
+	}
+
+	// The schema is built from strings that can be marshaled, so the error is
+	// ignored.
+	parameters, _ := json.Marshal(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"input": map[string]string{
+				"type":        "string",
+				"description": input.String(),
+			},
+		},
+		"required": []string{"input"},
+	})
+	return llms.FunctionDeclaration{
+		Name:        t.Name,
+		Description: t.Description,
+		Parameters:  parameters,
+	}
+}
+
+// Input returns the input for a tool from the LLM's call to its function
+// (see Declaration). The ErrInvalidToolInput error is returned when the call
+// has no input.
+func Input(call llms.FunctionCall) (string, error) {
+	var args struct {
+		Input *string `json:"input"`
+	}
+	if err := json.Unmarshal(call.Args, &args); err != nil {
+		return "", fmt.Errorf("%w: failed to decode the arguments for %q: %v", ErrInvalidToolInput, call.Name, err)
+	}
+	if args.Input == nil {
+		return "", fmt.Errorf("%w: no input for %q", ErrInvalidToolInput, call.Name)
+	}
+	return *args.Input, nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tools_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/go-react/pkg/llms"
+	"github.com/google/go-react/pkg/tools"
+)
+
+func TestDeclaration(t *testing.T) {
+	t.Parallel()
+
+	d := tools.Tool{
+		Name:        "some-tool",
+		Description: "some-description",
+		Args:        []string{"a", "b"},
+		Examples:    []string{"[1][2]"},
+	}.Declaration()
+
+	if actual, expected := d.Name, "some-tool"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+	if actual, expected := d.Description, "some-description"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+
+	var schema struct {
+		Properties struct {
+			Input struct {
+				Type        string `json:"type"`
+				Description string `json:"description"`
+			} `json:"input"`
+		} `json:"properties"`
+		Required []string `json:"required"`
+	}
+	if err := json.Unmarshal(d.Parameters, &schema); err != nil {
+		t.Fatal(err)
+	}
+	if actual, expected := schema.Properties.Input.Type, "string"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+	if actual, expected := schema.Properties.Input.Description, "The input to the tool. Usage: [a][b] Examples: [1][2]"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+	if actual, expected := len(schema.Required), 1; actual != expected {
+		t.Fatalf("expected %d, got %d", expected, actual)
+	}
+}
+
+func TestInput(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		args     string
+		expected string
+		err      error
+	}{
+		{name: "input", args: `{"input": "some-input"}`, expected: "some-input"},
+		{name: "empty input", args: `{"input": ""}`, expected: ""},
+		{name: "no input", args: `{}`, err: tools.ErrInvalidToolInput},
+		{name: "invalid args", args: `[]`, err: tools.ErrInvalidToolInput},
+	}
+
+	for _, tc := range testCases {
+		// Avoid issues with closure.
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			input, err := tools.Input(llms.FunctionCall{Name: "some-tool", Args: json.RawMessage(tc.args)})
+			if actual, expected := errors.Is(err, tc.err), true; actual != expected {
+				t.Fatalf("expected %v, got %v: %v", expected, actual, err)
+			}
+			if actual, expected := input, tc.expected; actual != expected {
+				t.Fatalf("expected %q, got %q", expected, actual)
+			}
+		})
+	}
+}
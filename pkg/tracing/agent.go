@@ -0,0 +1,94 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/google/go-react/pkg/agents"
+	"github.com/google/go-react/pkg/chains"
+	"github.com/google/go-react/pkg/predictors"
+	"github.com/google/go-react/pkg/tools"
+)
+
+// NewAgent returns a Chain that runs the agent in a span named
+// "invoke_agent {name}". Use NewAgentPredictor and NewTools when creating the
+// agent to add spans for each of its iterations and tool calls.
+func NewAgent[TOut any](agent agents.Agent[TOut], name string, opts ...Option) chains.Chain[string, TOut] {
+	tracer := newTracer(opts)
+	return chains.ChainFunc[string, TOut](func(ctx context.Context, goal string) (out TOut, err error) {
+		ctx, span := start(ctx, tracer, "invoke_agent "+name, trace.SpanKindInternal,
+			AttrOperationName.String("invoke_agent"),
+			AttrAgentName.String(name),
+		)
+		defer func() { end(span, err) }()
+		return agent.Run(ctx, goal)
+	})
+}
+
+// NewAgentPredictor returns a Predictor for agents.NewAgent that creates a
+// span named "agent iteration" for each iteration of the agent. The iteration
+// number and the name of the tool the LLM picked are set as attributes.
+func NewAgentPredictor[TOut any](
+	p predictors.Predictor[agents.PromptData[TOut], agents.Reasoning[TOut]],
+	opts ...Option,
+) predictors.Predictor[agents.PromptData[TOut], agents.Reasoning[TOut]] {
+	return agentPredictor[TOut]{
+		p:      p,
+		tracer: newTracer(opts),
+	}
+}
+
+type agentPredictor[TOut any] struct {
+	p      predictors.Predictor[agents.PromptData[TOut], agents.Reasoning[TOut]]
+	tracer trace.Tracer
+}
+
+// Predict implements predictors.Predictor.
+func (a agentPredictor[TOut]) Predict(ctx context.Context, req agents.PromptData[TOut]) (resp agents.Reasoning[TOut], err error) {
+	ctx, span := start(ctx, a.tracer, "agent iteration", trace.SpanKindInternal,
+		AttrAgentIteration.Int(len(req.Chains)+1),
+	)
+	defer func() { end(span, err) }()
+
+	resp, err = a.p.Predict(ctx, req)
+	if err == nil && resp.Action != "" {
+		span.SetAttributes(AttrToolName.String(resp.Action))
+	}
+	return resp, err
+}
+
+// NewTools returns the tools with their Run wrapped to create a span named
+// "execute_tool {name}" for each call.
+func NewTools(toolSet []tools.Tool, opts ...Option) []tools.Tool {
+	tracer := newTracer(opts)
+	traced := make([]tools.Tool, 0, len(toolSet))
+	for _, t := range toolSet {
+		t := t
+		run := t.Run
+		t.Run = func(ctx context.Context, input string) (out any, err error) {
+			ctx, span := start(ctx, tracer, "execute_tool "+t.Name, trace.SpanKindInternal,
+				AttrOperationName.String("execute_tool"),
+				AttrToolName.String(t.Name),
+			)
+			defer func() { end(span, err) }()
+			return run(ctx, input)
+		}
+		traced = append(traced, t)
+	}
+	return traced
+}
@@ -0,0 +1,139 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/google/go-react/pkg/llms"
+)
+
+// NewLLM returns an LLM that creates a span for each request. The system
+// (e.g., vertex_ai) and the model, which is read from the params with the
+// given function, are set as attributes along with the token usage and
+// finish reason when the LLM reports them. The model function may be nil.
+func NewLLM[TParams any](llm llms.LLM[TParams], system string, model func(TParams) string, opts ...Option) llms.LLM[TParams] {
+	return tracedLLM[TParams]{
+		llm:    llm,
+		system: system,
+		model:  model,
+		tracer: newTracer(opts),
+	}
+}
+
+type tracedLLM[TParams any] struct {
+	llm    llms.LLM[TParams]
+	system string
+	model  func(TParams) string
+	tracer trace.Tracer
+}
+
+// Generate implements llms.LLM.
+func (l tracedLLM[TParams]) Generate(ctx context.Context, prompt string, params TParams) (string, error) {
+	result, err := l.GenerateWithMetadata(ctx, prompt, params)
+	if err != nil {
+		return "", err
+	}
+	return result.Text, nil
+}
+
+// GenerateWithMetadata implements llms.MetadataLLM.
+func (l tracedLLM[TParams]) GenerateWithMetadata(ctx context.Context, prompt string, params TParams) (result llms.Result, err error) {
+	ctx, span := l.start(ctx, params)
+	defer func() { end(span, err) }()
+
+	result, err = llms.GenerateWithMetadata(ctx, l.llm, prompt, params)
+	if err != nil {
+		return llms.Result{}, err
+	}
+	span.SetAttributes(resultAttributes(result)...)
+	return result, nil
+}
+
+// GenerateStream implements llms.StreamLLM. The span ends once the stream has
+// been fully read or closed.
+func (l tracedLLM[TParams]) GenerateStream(ctx context.Context, prompt string, params TParams) (llms.Stream, error) {
+	ctx, span := l.start(ctx, params)
+	s, err := llms.GenerateStream(ctx, l.llm, prompt, params)
+	if err != nil {
+		end(span, err)
+		return nil, err
+	}
+	return &tracedStream{s: s, span: span}, nil
+}
+
+func (l tracedLLM[TParams]) start(ctx context.Context, params TParams) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		AttrSystem.String(l.system),
+		AttrOperationName.String("text_completion"),
+	}
+	name := "text_completion"
+	if l.model != nil {
+		model := l.model(params)
+		attrs = append(attrs, AttrRequestModel.String(model))
+		name += " " + model
+	}
+	return start(ctx, l.tracer, name, trace.SpanKindClient, attrs...)
+}
+
+func resultAttributes(result llms.Result) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if result.Usage != (llms.Usage{}) {
+		attrs = append(attrs,
+			AttrInputTokens.Int(result.Usage.InputTokens),
+			AttrOutputTokens.Int(result.Usage.OutputTokens),
+		)
+	}
+	if result.FinishReason != llms.FinishReasonUnknown {
+		attrs = append(attrs, AttrFinishReasons.StringSlice([]string{string(result.FinishReason)}))
+	}
+	return attrs
+}
+
+type tracedStream struct {
+	s     llms.Stream
+	span  trace.Span
+	ended bool
+}
+
+// Recv implements llms.Stream.
+func (s *tracedStream) Recv() (string, error) {
+	chunk, err := s.s.Recv()
+	if errors.Is(err, io.EOF) {
+		s.end(nil)
+	} else if err != nil {
+		s.end(err)
+	}
+	return chunk, err
+}
+
+// Close implements llms.Stream.
+func (s *tracedStream) Close() error {
+	s.end(nil)
+	return s.s.Close()
+}
+
+func (s *tracedStream) end(err error) {
+	if s.ended {
+		return
+	}
+	s.ended = true
+	end(s.span, err)
+}
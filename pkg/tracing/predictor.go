@@ -0,0 +1,83 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/google/go-react/pkg/chains"
+	"github.com/google/go-react/pkg/predictors"
+	"github.com/google/go-react/pkg/prompters"
+)
+
+// NewPrompter returns a Prompter that creates a span named "hydrate {name}"
+// for each prompt it hydrates.
+func NewPrompter[TPrompt, TLLMParams any](p prompters.Prompter[TPrompt, TLLMParams], name string, opts ...Option) prompters.Prompter[TPrompt, TLLMParams] {
+	return tracedPrompter[TPrompt, TLLMParams]{
+		p:      p,
+		name:   name,
+		tracer: newTracer(opts),
+	}
+}
+
+type tracedPrompter[TPrompt, TLLMParams any] struct {
+	p      prompters.Prompter[TPrompt, TLLMParams]
+	name   string
+	tracer trace.Tracer
+}
+
+// Hydrate implements prompters.Prompter.
+func (t tracedPrompter[TPrompt, TLLMParams]) Hydrate(ctx context.Context, p TPrompt) (prompt string, params TLLMParams, err error) {
+	ctx, span := start(ctx, t.tracer, "hydrate "+t.name, trace.SpanKindInternal, AttrName.String(t.name))
+	defer func() { end(span, err) }()
+	return t.p.Hydrate(ctx, p)
+}
+
+// NewPredictor returns a Predictor that creates a span named "predict {name}"
+// for each prediction. Wrapping both sides of a predictors.NewRetrier shows
+// each retry as its own span.
+func NewPredictor[TReq, TResp any](p predictors.Predictor[TReq, TResp], name string, opts ...Option) predictors.Predictor[TReq, TResp] {
+	return tracedPredictor[TReq, TResp]{
+		p:      p,
+		name:   name,
+		tracer: newTracer(opts),
+	}
+}
+
+type tracedPredictor[TReq, TResp any] struct {
+	p      predictors.Predictor[TReq, TResp]
+	name   string
+	tracer trace.Tracer
+}
+
+// Predict implements predictors.Predictor.
+func (t tracedPredictor[TReq, TResp]) Predict(ctx context.Context, req TReq) (resp TResp, err error) {
+	ctx, span := start(ctx, t.tracer, "predict "+t.name, trace.SpanKindInternal, AttrName.String(t.name))
+	defer func() { end(span, err) }()
+	return t.p.Predict(ctx, req)
+}
+
+// NewChain returns a Chain that creates a span named "chain {name}" for each
+// run.
+func NewChain[TIn, TOut any](c chains.Chain[TIn, TOut], name string, opts ...Option) chains.Chain[TIn, TOut] {
+	tracer := newTracer(opts)
+	return chains.ChainFunc[TIn, TOut](func(ctx context.Context, in TIn) (out TOut, err error) {
+		ctx, span := start(ctx, tracer, "chain "+name, trace.SpanKindInternal, AttrName.String(name))
+		defer func() { end(span, err) }()
+		return c.Run(ctx, in)
+	})
+}
@@ -0,0 +1,79 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the tracer.
+const instrumentationName = "github.com/google/go-react/pkg/tracing"
+
+// The GenAI semantic convention attributes, along with the go-react specific
+// ones.
+const (
+	AttrSystem         = attribute.Key("gen_ai.system")
+	AttrOperationName  = attribute.Key("gen_ai.operation.name")
+	AttrRequestModel   = attribute.Key("gen_ai.request.model")
+	AttrFinishReasons  = attribute.Key("gen_ai.response.finish_reasons")
+	AttrInputTokens    = attribute.Key("gen_ai.usage.input_tokens")
+	AttrOutputTokens   = attribute.Key("gen_ai.usage.output_tokens")
+	AttrToolName       = attribute.Key("gen_ai.tool.name")
+	AttrAgentName      = attribute.Key("gen_ai.agent.name")
+	AttrName           = attribute.Key("go_react.name")
+	AttrAgentIteration = attribute.Key("go_react.agent.iteration")
+)
+
+// Option is an option for the wrappers in this package.
+type Option func(*config)
+
+type config struct {
+	tp trace.TracerProvider
+}
+
+// WithTracerProvider sets the TracerProvider used to create spans. It
+// defaults to the global TracerProvider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tp = tp
+	}
+}
+
+func newTracer(opts []Option) trace.Tracer {
+	c := config{tp: otel.GetTracerProvider()}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c.tp.Tracer(instrumentationName)
+}
+
+// end records the error, if any, and ends the span.
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// start starts a span. It is a shorthand used by the wrappers.
+func start(ctx context.Context, tracer trace.Tracer, name string, kind trace.SpanKind, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+}
@@ -0,0 +1,266 @@
+// Copyright 2023 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/google/go-react/pkg/agents"
+	"github.com/google/go-react/pkg/chains"
+	"github.com/google/go-react/pkg/llms"
+	llmstesting "github.com/google/go-react/pkg/llms/testing"
+	predictorstesting "github.com/google/go-react/pkg/predictors/testing"
+	prompterstesting "github.com/google/go-react/pkg/prompters/testing"
+	"github.com/google/go-react/pkg/tools"
+	"github.com/google/go-react/pkg/tracing"
+)
+
+type params struct {
+	Model string
+}
+
+func model(p params) string {
+	return p.Model
+}
+
+func newExporter() (*tracetest.InMemoryExporter, tracing.Option) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	return exporter, tracing.WithTracerProvider(tp)
+}
+
+func attr(span tracetest.SpanStub, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestLLM(t *testing.T) {
+	t.Parallel()
+
+	exporter, opt := newExporter()
+	fake := &llmstesting.Fake[params]{
+		Results: map[string]llms.Result{
+			"some-prompt": {
+				Text:         "some-output",
+				FinishReason: llms.FinishReasonStop,
+				Usage:        llms.Usage{InputTokens: 3, OutputTokens: 5},
+			},
+		},
+	}
+	llm := tracing.NewLLM[params](fake, "vertex_ai", model, opt)
+
+	output, err := llm.Generate(context.Background(), "some-prompt", params{Model: "gemini-pro"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if actual, expected := output, "some-output"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+
+	spans := exporter.GetSpans()
+	if actual, expected := len(spans), 1; actual != expected {
+		t.Fatalf("expected %d spans, got %d", expected, actual)
+	}
+	span := spans[0]
+	if actual, expected := span.Name, "text_completion gemini-pro"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+
+	for key, expected := range map[attribute.Key]string{
+		tracing.AttrSystem:        "vertex_ai",
+		tracing.AttrRequestModel:  "gemini-pro",
+		tracing.AttrInputTokens:   "3",
+		tracing.AttrOutputTokens:  "5",
+		tracing.AttrFinishReasons: `["stop"]`,
+	} {
+		v, ok := attr(span, key)
+		if !ok {
+			t.Fatalf("expected attribute %q", key)
+		}
+		if actual := v.Emit(); actual != expected {
+			t.Fatalf("expected %s=%q, got %q", key, expected, actual)
+		}
+	}
+}
+
+func TestLLM_error(t *testing.T) {
+	t.Parallel()
+
+	exporter, opt := newExporter()
+	fake := &llmstesting.Fake[params]{Err: llms.ErrRateLimited}
+	llm := tracing.NewLLM[params](fake, "vertex_ai", nil, opt)
+
+	if _, err := llm.Generate(context.Background(), "some-prompt", params{}); !errors.Is(err, llms.ErrRateLimited) {
+		t.Fatalf("expected %v, got %v", llms.ErrRateLimited, err)
+	}
+
+	spans := exporter.GetSpans()
+	if actual, expected := len(spans), 1; actual != expected {
+		t.Fatalf("expected %d spans, got %d", expected, actual)
+	}
+	if actual, expected := spans[0].Name, "text_completion"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+	if actual, expected := spans[0].Status.Code, codes.Error; actual != expected {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestLLM_stream(t *testing.T) {
+	t.Parallel()
+
+	exporter, opt := newExporter()
+	fake := &llmstesting.Fake[params]{
+		Chunks: map[string][]string{"some-prompt": {"a", "b"}},
+	}
+	llm := tracing.NewLLM[params](fake, "vertex_ai", model, opt)
+
+	s, err := llms.GenerateStream(context.Background(), llm, "some-prompt", params{Model: "gemini-pro"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer s.Close()
+
+	for {
+		if _, err := s.Recv(); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if actual, expected := len(exporter.GetSpans()), 0; actual != expected {
+			t.Fatalf("expected %d spans before the stream ended, got %d", expected, actual)
+		}
+	}
+
+	if actual, expected := len(exporter.GetSpans()), 1; actual != expected {
+		t.Fatalf("expected %d spans, got %d", expected, actual)
+	}
+}
+
+func TestPredictor(t *testing.T) {
+	t.Parallel()
+
+	exporter, opt := newExporter()
+	prompter := tracing.NewPrompter[string, params](&prompterstesting.Fake[string, params]{}, "some-prompter", opt)
+	predictor := tracing.NewPredictor[string, string](&predictorstesting.Fake[string, string]{
+		Resps: []string{"some-resp"},
+	}, "some-predictor", opt)
+	chain := tracing.NewChain(chains.ChainFunc[string, string](func(ctx context.Context, in string) (string, error) {
+		if _, _, err := prompter.Hydrate(ctx, in); err != nil {
+			return "", err
+		}
+		return predictor.Predict(ctx, in)
+	}), "some-chain", opt)
+
+	if _, err := chain.Run(context.Background(), "some-req"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if actual, expected := len(spans), 3; actual != expected {
+		t.Fatalf("expected %d spans, got %d", expected, actual)
+	}
+
+	// Spans are exported as they end, so the chain is last.
+	root := spans[2]
+	if actual, expected := root.Name, "chain some-chain"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+	for i, expected := range []string{"hydrate some-prompter", "predict some-predictor"} {
+		if actual := spans[i].Name; actual != expected {
+			t.Fatalf("expected %q, got %q", expected, actual)
+		}
+		if actual, expected := spans[i].Parent.SpanID(), root.SpanContext.SpanID(); actual != expected {
+			t.Fatalf("expected %q to be a child of the chain, got parent %v", spans[i].Name, actual)
+		}
+	}
+}
+
+func TestAgent(t *testing.T) {
+	t.Parallel()
+
+	exporter, opt := newExporter()
+	predictor := tracing.NewAgentPredictor[string](&predictorstesting.Fake[agents.PromptData[string], agents.Reasoning[string]]{
+		Resps: []agents.Reasoning[string]{
+			{Thought: "some-thought", Action: "some-tool", Input: "some-input"},
+			{Thought: "some-thought", FinalAnswer: "some-answer"},
+		},
+	}, opt)
+	toolSet := tracing.NewTools([]tools.Tool{{
+		Name:        "some-tool",
+		Description: "some-description",
+		Run: func(ctx context.Context, input string) (any, error) {
+			return "some-observation", nil
+		},
+	}}, opt)
+	agent := tracing.NewAgent(agents.NewAgent(predictor, toolSet...), "some-agent", opt)
+
+	answer, err := agent.Run(context.Background(), "some-goal")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if actual, expected := answer, "some-answer"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+
+	spans := exporter.GetSpans()
+	if actual, expected := len(spans), 4; actual != expected {
+		t.Fatalf("expected %d spans, got %d", expected, actual)
+	}
+
+	root := spans[3]
+	if actual, expected := root.Name, "invoke_agent some-agent"; actual != expected {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+	if v, _ := attr(root, tracing.AttrAgentName); v.AsString() != "some-agent" {
+		t.Fatalf("expected agent name %q, got %q", "some-agent", v.AsString())
+	}
+
+	for i, tc := range []struct {
+		name      string
+		iteration int64
+		tool      string
+	}{
+		{name: "agent iteration", iteration: 1, tool: "some-tool"},
+		{name: "execute_tool some-tool", tool: "some-tool"},
+		{name: "agent iteration", iteration: 2},
+	} {
+		span := spans[i]
+		if actual, expected := span.Name, tc.name; actual != expected {
+			t.Fatalf("expected %q, got %q", expected, actual)
+		}
+		if actual, expected := span.Parent.SpanID(), root.SpanContext.SpanID(); actual != expected {
+			t.Fatalf("expected %q to be a child of the agent, got parent %v", span.Name, actual)
+		}
+		if v, _ := attr(span, tracing.AttrAgentIteration); v.AsInt64() != tc.iteration {
+			t.Fatalf("expected iteration %d, got %d", tc.iteration, v.AsInt64())
+		}
+		if v, _ := attr(span, tracing.AttrToolName); v.AsString() != tc.tool {
+			t.Fatalf("expected tool %q, got %q", tc.tool, v.AsString())
+		}
+	}
+}